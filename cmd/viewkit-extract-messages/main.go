@@ -0,0 +1,89 @@
+// Command viewkit-extract-messages extracts the messages to translate from the templates
+// and writes them as a gettext .pot file.
+//
+// Usage:
+//
+//	go run github.com/kohkimakimoto/echo-viewkit/cmd/viewkit-extract-messages -dir views -o locales/messages.pot
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kohkimakimoto/echo-viewkit/pongo2"
+)
+
+func main() {
+	dir := flag.String("dir", "views", "directory of the template files")
+	ext := flag.String("ext", ".html", "comma separated file extensions of the template files")
+	prefix := flag.String("prefix", "x-", "prefix of the component HTML tags (empty to disable the HTML syntax)")
+	output := flag.String("o", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if err := run(*dir, strings.Split(*ext, ","), *prefix, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, exts []string, prefix string, output string) error {
+	var messages []*pongo2.ExtractedMessage
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !hasExt(path, exts) {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if prefix != "" {
+			// expand the component HTML tags, so that the messages in their attributes are extracted
+			var buf bytes.Buffer
+			preProcessor := pongo2.ComponentHTMLTagPreProcessor(pongo2.ComponentHTMLTagPreProcessorConfig{TagPrefix: prefix})
+			if err := preProcessor.Execute(&buf, bytes.NewReader(src)); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			src = buf.Bytes()
+		}
+
+		extracted, err := pongo2.ExtractMessages(filepath.ToSlash(path), string(src))
+		if err != nil {
+			return err
+		}
+		messages = append(messages, extracted...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return pongo2.WritePOT(w, pongo2.MergeMessages(messages))
+}
+
+func hasExt(path string, exts []string) bool {
+	for _, ext := range exts {
+		if ext = strings.TrimSpace(ext); ext != "" && strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,345 @@
+package viewkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kohkimakimoto/echo-viewkit/pongo2"
+	"github.com/labstack/echo/v4"
+)
+
+// ComponentGallery is a mountable set of handlers to browse the registered components,
+// and to render each component in isolation with example props and slots.
+//
+//	gallery := &viewkit.ComponentGallery{Renderer: v.MustRenderer()}
+//	gallery.Register(e.Group("/_components"))
+type ComponentGallery struct {
+	// Renderer is the renderer that has the components.
+	// The components are rendered with its shared context providers.
+	Renderer *Renderer
+	// Fixtures are the examples of the components keyed by the component name.
+	// The fixtures can also be declared in a file placed next to the component template,
+	// like “components/alert.fixtures.json” for “components/alert.html”.
+	Fixtures map[string][]*ComponentFixture
+	// Layout is an optional template to wrap the rendered component.
+	// The rendered component is passed to the template as the “content” variable.
+	Layout string
+
+	// templates are the parsed gallery templates keyed by the source
+	templates      map[string]*pongo2.Template
+	templatesMutex sync.RWMutex
+}
+
+// ComponentFixture is an example of a component.
+type ComponentFixture struct {
+	// Name is the name of the example.
+	Name string `json:"name"`
+	// Props are the props and attributes passed to the component.
+	Props map[string]any `json:"props"`
+	// Slots are the template sources of the slots keyed by the slot name.
+	// The key “slot” is the default slot.
+	Slots map[string]string `json:"slots"`
+}
+
+// Register registers the handlers to the group.
+// The index page lists the components, and “/:name” renders a component.
+// The query parameters of “/:name” select a fixture by “fixture”, and override the props declared in Go
+// and the props of the fixture by the others. The values are converted to the types of the props,
+// and the values of the slice and map props are JSON.
+func (g *ComponentGallery) Register(group *echo.Group) {
+	group.GET("", g.indexHandler)
+	group.GET("/", g.indexHandler)
+	group.GET("/:name", g.componentHandler)
+}
+
+type galleryComponent struct {
+	*pongo2.ComponentInfo
+	Fixtures []*ComponentFixture
+}
+
+func (g *ComponentGallery) indexHandler(c echo.Context) error {
+	var components []*galleryComponent
+	for _, info := range g.Renderer.TemplateSet().ComponentSet.Components() {
+		fixtures, err := g.fixtures(info)
+		if err != nil {
+			return err
+		}
+		components = append(components, &galleryComponent{ComponentInfo: info, Fixtures: fixtures})
+	}
+
+	tpl, err := g.template(galleryIndexTemplate)
+	if err != nil {
+		return err
+	}
+	out, err := tpl.Execute(pongo2.Context{
+		"base":       strings.TrimSuffix(c.Path(), "/"),
+		"components": components,
+	})
+	if err != nil {
+		return err
+	}
+	return c.HTML(http.StatusOK, out)
+}
+
+func (g *ComponentGallery) componentHandler(c echo.Context) error {
+	info := g.Renderer.TemplateSet().ComponentSet.Component(c.Param("name"))
+	if info == nil {
+		return echo.ErrNotFound
+	}
+
+	fixtures, err := g.fixtures(info)
+	if err != nil {
+		return err
+	}
+
+	// select the fixture
+	fixture := &ComponentFixture{}
+	if name := c.QueryParam("fixture"); name != "" {
+		fixture = nil
+		for _, f := range fixtures {
+			if f.Name == name {
+				fixture = f
+				break
+			}
+		}
+		if fixture == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("fixture '%s' not found", name))
+		}
+	} else if len(fixtures) > 0 {
+		fixture = fixtures[0]
+	}
+
+	// override the props by the query parameters
+	props := map[string]any{}
+	for k, v := range fixture.Props {
+		props[k] = v
+	}
+	specs := map[string]*pongo2.PropSpec{}
+	for _, spec := range info.Props {
+		specs[spec.Name] = spec
+	}
+	for k, v := range c.QueryParams() {
+		if k == "fixture" || len(v) == 0 {
+			continue
+		}
+		spec, declared := specs[k]
+		if _, ok := fixture.Props[k]; !declared && !ok {
+			continue
+		}
+		val, err := galleryQueryValue(spec, k, v[0])
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		props[k] = val
+	}
+
+	src, data, err := galleryComponentSource(info.Name, props, fixture.Slots)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	tpl, err := g.template(src)
+	if err != nil {
+		return err
+	}
+	ctx, err := g.Renderer.newContext(data, c)
+	if err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	if err := tpl.ExecuteWriterWithEchoContext(ctx, buf, c); err != nil {
+		return unwrapRenderError(err)
+	}
+
+	if g.Layout != "" {
+		return Render(g.Renderer, c, http.StatusOK, g.Layout, map[string]any{
+			"content": pongo2.AsSafeValue(buf.String()),
+		})
+	}
+	return c.HTML(http.StatusOK, fmt.Sprintf(galleryComponentPage, html.EscapeString(info.Name), buf.String()))
+}
+
+// fixtures returns the fixtures declared in Go and the fixtures declared in the file next to the component template.
+func (g *ComponentGallery) fixtures(info *pongo2.ComponentInfo) ([]*ComponentFixture, error) {
+	fixtures := append([]*ComponentFixture{}, g.Fixtures[info.Name]...)
+
+	cs := g.Renderer.TemplateSet().ComponentSet
+	if info.TemplateFile == "" || cs.TemplateSetFS == nil || strings.Contains(info.TemplateFile, pongo2.NamespaceSeparator) {
+		return fixtures, nil
+	}
+
+	file := info.TemplateFile
+	if ext := filepath.Ext(file); ext != "" && ext == cs.DefaultTemplateFileExtension {
+		file = strings.TrimSuffix(file, ext)
+	}
+	b, err := fs.ReadFile(cs.TemplateSetFS, file+pongo2.ComponentFixturesFileSuffix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fixtures, nil
+		}
+		return nil, err
+	}
+
+	var fileFixtures []*ComponentFixture
+	if err := json.Unmarshal(b, &fileFixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse the fixtures of the component '%s': %w", info.Name, err)
+	}
+	return append(fixtures, fileFixtures...), nil
+}
+
+// template returns the parsed template of the source.
+// The templates are parsed once and cached unless the template set is in debug mode.
+func (g *ComponentGallery) template(src string) (*pongo2.Template, error) {
+	set := g.Renderer.TemplateSet()
+	if set.Debug {
+		return set.FromString(src)
+	}
+
+	g.templatesMutex.RLock()
+	tpl, ok := g.templates[src]
+	g.templatesMutex.RUnlock()
+	if ok {
+		return tpl, nil
+	}
+
+	tpl, err := set.FromString(src)
+	if err != nil {
+		return nil, err
+	}
+
+	g.templatesMutex.Lock()
+	if g.templates == nil {
+		g.templates = map[string]*pongo2.Template{}
+	}
+	g.templates[src] = tpl
+	g.templatesMutex.Unlock()
+	return tpl, nil
+}
+
+// galleryQueryValue converts the query parameter to the type of the prop.
+// The query parameters of the props that are not declared in Go are strings.
+func galleryQueryValue(spec *pongo2.PropSpec, name, s string) (any, error) {
+	if spec == nil {
+		return s, nil
+	}
+
+	var v any
+	var err error
+	switch spec.Type {
+	case pongo2.PropTypeInt:
+		v, err = strconv.Atoi(s)
+	case pongo2.PropTypeFloat:
+		v, err = strconv.ParseFloat(s, 64)
+	case pongo2.PropTypeBool:
+		v, err = strconv.ParseBool(s)
+	case pongo2.PropTypeSlice, pongo2.PropTypeMap:
+		err = json.Unmarshal([]byte(s), &v)
+	case pongo2.PropTypeStruct:
+		return nil, fmt.Errorf("prop '%s' of type %s can not be set by a query parameter", name, spec.Type)
+	default:
+		v = s
+	}
+	if err != nil {
+		return nil, fmt.Errorf("prop '%s' must be of type %s, got %q", name, spec.Type, s)
+	}
+	return v, nil
+}
+
+var galleryKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_:.-]+$`)
+
+// galleryComponentSource generates a template source to render the component with the props and the slots.
+// The component name and the prop values are passed by the returned data, not embedded in the source,
+// so the source is the same for the same prop names and slots.
+func galleryComponentSource(name string, props map[string]any, slots map[string]string) (string, map[string]any, error) {
+	data := map[string]any{"gallery_component": name}
+
+	var b strings.Builder
+	b.WriteString(`{% component gallery_component`)
+	if len(props) > 0 {
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			if !galleryKeyRegex.MatchString(k) {
+				return "", nil, fmt.Errorf("invalid prop name '%s'", k)
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b.WriteString(" withAttrs")
+		for i, k := range keys {
+			v := fmt.Sprintf("gallery_prop_%d", i)
+			fmt.Fprintf(&b, ` "%s"=%s`, k, v)
+			data[v] = props[k]
+		}
+	}
+	b.WriteString(" %}")
+
+	// The named slots must precede the default slot.
+	slotNames := make([]string, 0, len(slots))
+	for k := range slots {
+		if k != "slot" {
+			if !galleryKeyRegex.MatchString(k) {
+				return "", nil, fmt.Errorf("invalid slot name '%s'", k)
+			}
+			slotNames = append(slotNames, k)
+		}
+	}
+	sort.Strings(slotNames)
+	for _, k := range slotNames {
+		fmt.Fprintf(&b, `{%% slot "%s" %%}%s{%% endslot %%}`, k, slots[k])
+	}
+	b.WriteString(slots["slot"])
+	b.WriteString("{% endcomponent %}")
+
+	return b.String(), data, nil
+}
+
+const galleryIndexTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Components</title>
+</head>
+<body>
+<h1>Components</h1>
+<ul>
+{% for c in components %}
+  <li>
+    <a href="{{ base }}/{{ c.Name|urlencode }}">{{ c.Name }}</a>
+    <small>{{ c.Kind }}{% if c.TemplateFile %} {{ c.TemplateFile }}{% endif %}</small>
+    {% if c.Props %}<div>props: {% for p in c.Props %}{{ p.Name }}{% if p.Required %}*{% endif %}{% if not forloop.Last %}, {% endif %}{% endfor %}</div>{% endif %}
+    {% if c.Fixtures %}
+    <ul>
+    {% for f in c.Fixtures %}
+      <li><a href="{{ base }}/{{ c.Name|urlencode }}?fixture={{ f.Name|urlencode }}">{{ f.Name }}</a></li>
+    {% endfor %}
+    </ul>
+    {% endif %}
+  </li>
+{% endfor %}
+</ul>
+</body>
+</html>
+`
+
+const galleryComponentPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+</head>
+<body>
+%s
+</body>
+</html>
+`
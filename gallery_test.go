@@ -0,0 +1,147 @@
+package viewkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kohkimakimoto/echo-viewkit/pongo2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGallery(t *testing.T) (*echo.Echo, *ComponentGallery) {
+	v := New()
+	v.FS = fstest.MapFS{
+		"components/alert.html":          {Data: []byte(`{% props type="info" %}<div class="alert-{{ type }}" {{ attributes }}>{{ slot }}</div>`)},
+		"components/alert.fixtures.json": {Data: []byte(`[{"name": "info", "slots": {"slot": "Hello"}}, {"name": "warning", "props": {"type": "warning", "class": "mt-4"}, "slots": {"slot": "<b>Careful</b>"}}]`)},
+		"layout.html":                    {Data: []byte(`<main>{{ content }}</main>`)},
+	}
+	v.AnonymousComponentsDirectories = []*pongo2.AnonymousComponentsDirectory{{Dir: "components"}}
+	v.InlineComponents = []*pongo2.InlineComponent{
+		{
+			Name:           "counter",
+			TemplateString: `{{ count + 1 }}:{{ items|join:"," }}:{{ label }}`,
+			PropSpecs: []*pongo2.PropSpec{
+				{Name: "count", Type: pongo2.PropTypeInt, Default: 0},
+				{Name: "items", Type: pongo2.PropTypeSlice},
+				{Name: "label"},
+			},
+		},
+		{
+			Name:           `quote"name`,
+			TemplateString: `quoted`,
+		},
+		{
+			Name:           "private",
+			TemplateString: `private`,
+			Setup: func(ctx *pongo2.ComponentExecutionContext) error {
+				return echo.ErrForbidden
+			},
+		},
+	}
+	r, err := v.Renderer()
+	assert.NoError(t, err)
+
+	e := echo.New()
+	g := &ComponentGallery{
+		Renderer: r,
+		Fixtures: map[string][]*ComponentFixture{
+			"counter": {{Name: "default", Props: map[string]any{"items": []string{"a"}}}},
+		},
+	}
+	g.Register(e.Group("/_components"))
+	return e, g
+}
+
+func galleryRequest(e *echo.Echo, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestComponentGallery(t *testing.T) {
+	t.Run("index", func(t *testing.T) {
+		e, _ := newTestGallery(t)
+		rec := galleryRequest(e, "/_components")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<a href="/_components/alert">alert</a>`)
+		assert.Contains(t, rec.Body.String(), `<a href="/_components/alert?fixture=warning">warning</a>`)
+		assert.Contains(t, rec.Body.String(), `props: count, items, label`)
+	})
+
+	t.Run("fixtures", func(t *testing.T) {
+		e, _ := newTestGallery(t)
+		rec := galleryRequest(e, "/_components/alert")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<div class="alert-info" >Hello</div>`)
+
+		rec = galleryRequest(e, "/_components/alert?fixture=warning")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<div class="alert-warning" class="mt-4"><b>Careful</b></div>`)
+
+		rec = galleryRequest(e, "/_components/alert?fixture=missing")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = galleryRequest(e, "/_components/missing")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("override props by query parameters", func(t *testing.T) {
+		e, _ := newTestGallery(t)
+		rec := galleryRequest(e, `/_components/counter?count=2&items=["x","y"]&label=<i>&unknown=1`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `3:x,y:&lt;i&gt;`)
+		assert.NotContains(t, rec.Body.String(), `unknown`)
+
+		rec = galleryRequest(e, "/_components/alert?fixture=warning&type=danger")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<div class="alert-danger" class="mt-4"><b>Careful</b></div>`)
+
+		// the props declared by the props tag of an anonymous component
+		rec = galleryRequest(e, "/_components/alert?type=danger")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<div class="alert-danger" >Hello</div>`)
+
+		rec = galleryRequest(e, "/_components/counter?count=x")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `prop 'count' must be of type int, got \"x\"`)
+
+		rec = galleryRequest(e, "/_components/counter?items=x")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("component names are not embedded in the template", func(t *testing.T) {
+		e, _ := newTestGallery(t)
+		rec := galleryRequest(e, "/_components/quote%22name")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<title>quote&#34;name</title>`)
+		assert.Contains(t, rec.Body.String(), "quoted")
+	})
+
+	t.Run("cache the templates", func(t *testing.T) {
+		e, g := newTestGallery(t)
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, http.StatusOK, galleryRequest(e, "/_components").Code)
+			assert.Equal(t, http.StatusOK, galleryRequest(e, "/_components/counter?count=1").Code)
+			assert.Equal(t, http.StatusOK, galleryRequest(e, "/_components/counter?count=2").Code)
+		}
+		assert.Len(t, g.templates, 2)
+	})
+
+	t.Run("http errors of components", func(t *testing.T) {
+		e, _ := newTestGallery(t)
+		rec := galleryRequest(e, "/_components/private")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		e, g := newTestGallery(t)
+		g.Layout = "layout"
+		rec := galleryRequest(e, "/_components/alert")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `<main><div class="alert-info" >Hello</div></main>`, rec.Body.String())
+	})
+}
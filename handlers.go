@@ -1,8 +1,12 @@
 package viewkit
 
 import (
+	"errors"
+	"io/fs"
 	"net/http"
+	"strings"
 
+	"github.com/kohkimakimoto/echo-viewkit/pongo2"
 	"github.com/labstack/echo/v4"
 )
 
@@ -20,3 +24,43 @@ func ViewHandlerWithData(name string, data any) echo.HandlerFunc {
 		return c.Render(http.StatusOK, name, data)
 	}
 }
+
+// ComponentLibraryAssetsHandler returns a handler that serves the static assets of the component libraries.
+// The first segment of the path parameter “*” is the namespace of the library, and the rest is the file path in the Assets of the library.
+//
+//	e.GET("/vendor/*", viewkit.ComponentLibraryAssetsHandler(v.ComponentLibraries...))
+//	// “/vendor/ui/button.css” serves “button.css” in the Assets of the “ui” library.
+func ComponentLibraryAssetsHandler(libs ...*pongo2.ComponentLibrary) echo.HandlerFunc {
+	assets := map[string]fs.FS{}
+	for _, lib := range libs {
+		if lib.Assets != nil {
+			assets[lib.Namespace] = lib.Assets
+		}
+	}
+
+	return func(c echo.Context) error {
+		namespace, file, _ := strings.Cut(strings.TrimPrefix(c.Param("*"), "/"), "/")
+		fsys, ok := assets[namespace]
+		if !ok || file == "" {
+			return echo.ErrNotFound
+		}
+		return echo.StaticFileHandler(file, fsys)(c)
+	}
+}
+
+// HTTPErrorHandler returns an error handler that redirects the request if the error is a redirect error returned by a component.
+// The other errors are handled by the next handler.
+//
+//	e.HTTPErrorHandler = viewkit.HTTPErrorHandler(e.DefaultHTTPErrorHandler)
+func HTTPErrorHandler(next echo.HTTPErrorHandler) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		var re *pongo2.RedirectError
+		if errors.As(err, &re) && !c.Response().Committed {
+			if err := c.Redirect(re.Code, re.URL); err != nil {
+				c.Logger().Error(err)
+			}
+			return
+		}
+		next(err, c)
+	}
+}
@@ -0,0 +1,189 @@
+package viewkit
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kohkimakimoto/echo-viewkit/pongo2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHandlers(t *testing.T) (*echo.Echo, *[]error) {
+	v := New()
+	v.FS = fstest.MapFS{
+		"forbidden.html": {Data: []byte(`before {{ forbid() }}`)},
+		"guarded.html":   {Data: []byte(`before {% component "guard" %}{% endcomponent %}`)},
+		"nested.html":    {Data: []byte(`before {% component "panel" %}{% endcomponent %}`)},
+		"redirect.html":  {Data: []byte(`before {% component "login" %}{% endcomponent %}`)},
+		"broken.html":    {Data: []byte(`before {% component "broken" %}{% endcomponent %}`)},
+	}
+	v.InlineComponents = []*pongo2.InlineComponent{
+		{
+			Name:           "guard",
+			TemplateString: `guarded`,
+			Setup: func(ctx *pongo2.ComponentExecutionContext) error {
+				return echo.NewHTTPError(http.StatusForbidden, "no access")
+			},
+		},
+		{
+			Name:           "panel",
+			TemplateString: `<div>{% component "guard" %}{% endcomponent %}</div>`,
+		},
+		{
+			Name:           "login",
+			TemplateString: `login`,
+			Setup: func(ctx *pongo2.ComponentExecutionContext) error {
+				return pongo2.Redirect(http.StatusFound, "/login")
+			},
+		},
+		{
+			Name:           "broken",
+			TemplateString: `broken`,
+			Setup: func(ctx *pongo2.ComponentExecutionContext) error {
+				return errors.New("broken component")
+			},
+		},
+	}
+	r, err := v.Renderer()
+	assert.NoError(t, err)
+
+	e := echo.New()
+	e.Renderer = r
+	var handled []error
+	e.HTTPErrorHandler = HTTPErrorHandler(func(err error, c echo.Context) {
+		handled = append(handled, err)
+		e.DefaultHTTPErrorHandler(err, c)
+	})
+	e.GET("/forbidden", ViewHandlerWithData("forbidden.html", map[string]any{
+		"forbid": func() (string, error) {
+			return "", echo.ErrForbidden
+		},
+	}))
+	e.GET("/guarded", ViewHandler("guarded.html"))
+	e.GET("/nested", ViewHandler("nested.html"))
+	e.GET("/redirect", ViewHandler("redirect.html"))
+	e.GET("/broken", ViewHandler("broken.html"))
+	return e, &handled
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	t.Run("HTTP error in a template", func(t *testing.T) {
+		e, handled := newTestHandlers(t)
+		rec := galleryRequest(e, "/forbidden")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "before")
+		if assert.Len(t, *handled, 1) {
+			assert.Equal(t, echo.ErrForbidden, (*handled)[0])
+		}
+	})
+
+	t.Run("HTTP error in a component", func(t *testing.T) {
+		e, handled := newTestHandlers(t)
+		rec := galleryRequest(e, "/guarded")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.JSONEq(t, `{"message": "no access"}`, rec.Body.String())
+		if assert.Len(t, *handled, 1) {
+			var he *echo.HTTPError
+			assert.True(t, errors.As((*handled)[0], &he))
+			assert.Same(t, he, (*handled)[0])
+		}
+	})
+
+	t.Run("HTTP error in a nested component", func(t *testing.T) {
+		e, _ := newTestHandlers(t)
+		rec := galleryRequest(e, "/nested")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.JSONEq(t, `{"message": "no access"}`, rec.Body.String())
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		e, handled := newTestHandlers(t)
+		rec := galleryRequest(e, "/redirect")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "/login", rec.Header().Get("Location"))
+		assert.NotContains(t, rec.Body.String(), "before")
+		assert.Empty(t, *handled)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		e, handled := newTestHandlers(t)
+		rec := galleryRequest(e, "/broken")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		if assert.Len(t, *handled, 1) {
+			assert.ErrorContains(t, (*handled)[0], "broken component")
+			var pe *pongo2.Error
+			assert.True(t, errors.As((*handled)[0], &pe))
+		}
+	})
+}
+
+func TestUnwrapRenderError(t *testing.T) {
+	assert.NoError(t, unwrapRenderError(nil))
+
+	he := echo.NewHTTPError(http.StatusNotFound)
+	assert.Same(t, he, unwrapRenderError(&pongo2.Error{OrigError: he}))
+
+	re := &pongo2.RedirectError{Code: http.StatusSeeOther, URL: "/"}
+	assert.Same(t, re, unwrapRenderError(&pongo2.Error{OrigError: re}))
+
+	err := errors.New("other")
+	assert.Equal(t, err, unwrapRenderError(err))
+}
+
+func TestComponentLibraryAssetsHandler(t *testing.T) {
+	e := echo.New()
+	e.GET("/vendor/*", ComponentLibraryAssetsHandler(
+		&pongo2.ComponentLibrary{
+			Namespace: "ui",
+			Assets: fstest.MapFS{
+				"button.css":  {Data: []byte(`.button {}`)},
+				"js/modal.js": {Data: []byte(`modal()`)},
+			},
+		},
+		&pongo2.ComponentLibrary{Namespace: "plain"},
+	))
+	e.GET("/secret.txt", func(c echo.Context) error {
+		return c.String(http.StatusOK, "secret")
+	})
+
+	t.Run("assets", func(t *testing.T) {
+		rec := galleryRequest(e, "/vendor/ui/button.css")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `.button {}`, rec.Body.String())
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/css")
+
+		rec = galleryRequest(e, "/vendor/ui/js/modal.js")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `modal()`, rec.Body.String())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, target := range []string{
+			"/vendor/",
+			"/vendor/ui",
+			"/vendor/ui/",
+			"/vendor/ui/missing.css",
+			"/vendor/unknown/button.css",
+			"/vendor/plain/button.css",
+		} {
+			rec := galleryRequest(e, target)
+			assert.Equal(t, http.StatusNotFound, rec.Code, target)
+		}
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		for _, target := range []string{
+			"/vendor/ui/../secret.txt",
+			"/vendor/ui/%2e%2e/secret.txt",
+			"/vendor/ui/..%2fsecret.txt",
+			"/vendor/ui/js/../../plain/button.css",
+		} {
+			rec := galleryRequest(e, target)
+			assert.Equal(t, http.StatusNotFound, rec.Code, target)
+			assert.NotContains(t, rec.Body.String(), "secret", target)
+		}
+	})
+}
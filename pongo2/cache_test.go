@@ -0,0 +1,48 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	t.Run("LRU eviction", func(t *testing.T) {
+		c := NewMemoryCache(2)
+		c.Set("a", "1", 0)
+		c.Set("b", "2", 0)
+		_, _ = c.Get("a")
+		c.Set("c", "3", 0)
+
+		_, ok := c.Get("b")
+		assert.False(t, ok)
+		v, ok := c.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, "1", v)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		now := time.Now()
+		c := NewMemoryCache(10)
+		c.now = func() time.Time { return now }
+		c.Set("a", "1", time.Minute)
+		c.Set("b", "2", 0)
+
+		now = now.Add(time.Minute)
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+		_, ok = c.Get("b")
+		assert.True(t, ok)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c := NewMemoryCache(10)
+		c.Set("component:nav:1", "1", 0)
+		c.Set("component:nav:2", "2", 0)
+		c.Set("component:card:1", "3", 0)
+		c.Delete("component:card:1")
+		c.DeletePrefix("component:nav:")
+		assert.Equal(t, 0, c.Len())
+	})
+}
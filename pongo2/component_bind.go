@@ -0,0 +1,229 @@
+package pongo2
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// NewComponent creates a component whose props are declared by the struct type P.
+// The fields of P become the props of the component, and the Setup function receives P populated from the passed props.
+// Changes to P in the Setup function are reflected in the template.
+//
+// A field is mapped to a prop by the "pongo2" tag. The "required" option makes the prop required,
+// and the "default" tag sets the default value:
+//
+//	type AlertProps struct {
+//		Message string `pongo2:"message,required"`
+//		Type    string `pongo2:"type" default:"info"`
+//	}
+//
+//	var Alert = pongo2.NewComponent("alert", "components/alert", func(ctx *pongo2.ComponentExecutionContext, props *AlertProps) error {
+//		return nil
+//	})
+//
+// It panics if P is not a struct type or has an invalid default value.
+func NewComponent[P any](name string, templateFile string, setup func(ctx *ComponentExecutionContext, props *P) error) *Component {
+	return &Component{
+		Name:         name,
+		TemplateFile: templateFile,
+		PropSpecs:    mustPropSpecsOf[P](),
+		Setup:        bindSetup(setup),
+	}
+}
+
+// NewInlineComponent creates an inline component whose props are declared by the struct type P.
+// See NewComponent for details.
+func NewInlineComponent[P any](name string, templateString string, setup func(ctx *ComponentExecutionContext, props *P) error) *InlineComponent {
+	return &InlineComponent{
+		Name:           name,
+		TemplateString: templateString,
+		PropSpecs:      mustPropSpecsOf[P](),
+		Setup:          bindSetup(setup),
+	}
+}
+
+func mustPropSpecsOf[P any]() []*PropSpec {
+	specs, err := PropSpecsFromStruct(new(P))
+	if err != nil {
+		panic(err)
+	}
+	return specs
+}
+
+func bindSetup[P any](setup func(ctx *ComponentExecutionContext, props *P) error) func(*ComponentExecutionContext) error {
+	return func(ctx *ComponentExecutionContext) error {
+		props := new(P)
+		if err := ctx.BindProps(props); err != nil {
+			return err
+		}
+		if setup != nil {
+			if err := setup(ctx, props); err != nil {
+				return err
+			}
+		}
+		setPropFields(ctx.Data, reflect.ValueOf(props).Elem())
+		return nil
+	}
+}
+
+// setPropFields sets the fields of the props struct into the data by the prop names.
+// The values are set as they are, so a time.Time or a struct prop keeps its Go type in the template.
+func setPropFields(data Context, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("pongo2")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+
+		if hasTagOption(parts[1:], "squash") {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			setPropFields(data, embedded)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		data[name] = v.Field(i).Interface()
+	}
+}
+
+// BindProps binds the component data to the struct pointed by out.
+// Unlike Bind, it does not convert the values weakly, because the props are already converted to the declared types.
+func (c *ComponentExecutionContext) BindProps(out any) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName:    "pongo2",
+		Result:     out,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(valueConvertHook),
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(c.Data)
+}
+
+// PropSpecsFromStruct generates prop specs from the fields of the struct.
+// See NewComponent for the supported tags.
+func PropSpecsFromStruct(v any) ([]*PropSpec, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("props must be a struct, got %T", v)
+	}
+	return propSpecsFromStructType(t)
+}
+
+func propSpecsFromStructType(t reflect.Type) ([]*PropSpec, error) {
+	specs := make([]*PropSpec, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("pongo2")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		options := parts[1:]
+
+		if hasTagOption(options, "squash") {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("field '%s' with the squash option must be a struct", field.Name)
+			}
+			embeddedSpecs, err := propSpecsFromStructType(embedded)
+			if err != nil {
+				return nil, err
+			}
+			specs = append(specs, embeddedSpecs...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		spec := &PropSpec{
+			Name:     name,
+			Type:     propTypeOf(field.Type),
+			Required: hasTagOption(options, "required"),
+		}
+
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if spec.Required {
+				return nil, fmt.Errorf("required prop '%s' can not have a default value", name)
+			}
+			if spec.Type == PropTypeAny {
+				spec.Default = def
+			} else {
+				converted, err := spec.convert(AsValue(def))
+				if err != nil {
+					return nil, fmt.Errorf("invalid default value for field '%s': %w", field.Name, err)
+				}
+				spec.Default = converted.Interface()
+			}
+		}
+
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func propTypeOf(t reflect.Type) PropType {
+	switch t.Kind() {
+	case reflect.String:
+		return PropTypeString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return PropTypeInt
+	case reflect.Float32, reflect.Float64:
+		return PropTypeFloat
+	case reflect.Bool:
+		return PropTypeBool
+	case reflect.Slice, reflect.Array:
+		return PropTypeSlice
+	case reflect.Map:
+		return PropTypeMap
+	case reflect.Struct:
+		return PropTypeStruct
+	case reflect.Ptr:
+		if t.Elem().Kind() == reflect.Struct {
+			return PropTypeStruct
+		}
+	}
+	return PropTypeAny
+}
+
+func hasTagOption(options []string, option string) bool {
+	for _, o := range options {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testAlertProps struct {
+	Message string `pongo2:"message,required"`
+	Type    string `pongo2:"type" default:"info"`
+	Count   int    `pongo2:"count" default:"1"`
+	Tags    []string
+}
+
+func TestPropSpecsFromStruct(t *testing.T) {
+	specs, err := PropSpecsFromStruct(&testAlertProps{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*PropSpec{
+		{Name: "message", Type: PropTypeString, Required: true},
+		{Name: "type", Type: PropTypeString, Default: "info"},
+		{Name: "count", Type: PropTypeInt, Default: 1},
+		{Name: "Tags", Type: PropTypeSlice},
+	}, specs)
+
+	_, err = PropSpecsFromStruct(&struct {
+		Count int `pongo2:"count" default:"abc"`
+	}{})
+	assert.ErrorContains(t, err, "invalid default value for field 'Count'")
+
+	_, err = PropSpecsFromStruct("string")
+	assert.Error(t, err)
+}
+
+func TestNewComponent(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	set.ComponentSet.RegisterInlineComponent(NewInlineComponent("alert", `{{ type }}:{{ message }}:{{ count }}`, func(ctx *ComponentExecutionContext, props *testAlertProps) error {
+		props.Message = strings.ToUpper(props.Message)
+		props.Count = props.Count * 2
+		return nil
+	}))
+
+	out, err := set.RenderTemplateString(`{% component "alert" withAttrs "message"="hello" "count"="3" %}{% endcomponent %} {% component "alert" withAttrs "message"="bye" %}{% endcomponent %}`, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "info:HELLO:6 info:BYE:2", out)
+
+	_, err = set.FromString(`{% component "alert" %}{% endcomponent %}`)
+	assert.ErrorContains(t, err, "missing required prop 'message'")
+}
+
+type testEventAuthor struct {
+	Name string
+}
+
+type testEventProps struct {
+	Created time.Time       `pongo2:"created"`
+	Author  testEventAuthor `pongo2:"author"`
+}
+
+func TestNewComponentGoValues(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	set.ComponentSet.RegisterInlineComponent(NewInlineComponent("event", `{{ created|date:"2006" }}:{{ author.Name }}`, func(ctx *ComponentExecutionContext, props *testEventProps) error {
+		props.Author.Name = strings.ToUpper(props.Author.Name)
+		return nil
+	}))
+
+	out, err := set.RenderTemplateString(`{% component "event" withAttrs "created"=created "author"=author %}{% endcomponent %}`, Context{
+		"created": time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"author":  testEventAuthor{Name: "john"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "2024:JOHN", out)
+}
@@ -0,0 +1,195 @@
+package pongo2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// componentCacheKeyPrefix is the prefix of the cache keys of the component output.
+// The cached output of a component can be removed by Cache.DeletePrefix("component:" + name + ":").
+const componentCacheKeyPrefix = "component:"
+
+// cacheTTLOf returns the cache TTL of the component.
+// The tag option “cache” (in seconds) takes precedence over the CacheTTL of the component.
+func (node *tagComponentNode) cacheTTLOf(ctx *ExecutionContext) (time.Duration, *Error) {
+	if node.cacheExpr == nil {
+		return node.component.CacheTTL, nil
+	}
+	val, err := node.cacheExpr.Evaluate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(val.Float() * float64(time.Second)), nil
+}
+
+// cacheable reports whether the output of the component can be cached.
+// A component with scoped slots is not cached, because the scoped slots are rendered by the component template
+// with its arguments, so their contents can't be a part of the cache key.
+func (node *tagComponentNode) cacheable() bool {
+	for _, slot := range node.slots {
+		if len(slot.args) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheKey returns the cache key of the component output.
+// The key is the component name and a hash of the template, the props, the attributes and the slots.
+// The props include the data set by the setup function and the shared context values except for the functions.
+// The slots are rendered to hash their contents, and the rendered contents are reused by the component template.
+func (node *tagComponentNode) cacheKey(templateFile string, data Context, attrPairs [][2]string, slots map[string]*Slot) (string, *Error) {
+	h := sha256.New()
+
+	// template chosen by the setup function or the template resolver
+	fmt.Fprintf(h, "template:%s\n", templateFile)
+
+	// props
+	keys := make([]string, 0, len(data))
+	for key, value := range data {
+		if key != "attributes" && !isFuncValue(value) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "prop:%s=%s\n", key, cacheKeyValue(data[key]))
+	}
+
+	// attributes
+	for _, pair := range attrPairs {
+		fmt.Fprintf(h, "attr:%s=%s\n", pair[0], pair[1])
+	}
+
+	// slots
+	for _, slot := range node.slots {
+		s := slots[slot.Name]
+		content, err := s.value()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "slot:%s[%s]=%q\n", slot.Name, s.Attributes.String(), content.String())
+	}
+
+	return componentCacheKeyPrefix + node.component.Name + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// maxCacheKeyValueDepth is the maximum depth of the values written to the cache keys.
+const maxCacheKeyValueDepth = 32
+
+var typeOfTime = reflect.TypeOf(time.Time{})
+
+// cacheKeyValue returns a representation of the value for the cache key.
+// It includes the unexported fields of the structs and the values that the pointers point to,
+// so different values have different representations.
+func cacheKeyValue(v any) string {
+	if val, ok := v.(*Value); ok {
+		v = val.Interface()
+	}
+	var b strings.Builder
+	writeCacheKeyValue(&b, reflect.ValueOf(v), make(map[uintptr]bool), 0)
+	return b.String()
+}
+
+func writeCacheKeyValue(b *strings.Builder, v reflect.Value, visited map[uintptr]bool, depth int) {
+	if !v.IsValid() {
+		b.WriteString("nil")
+		return
+	}
+	if depth > maxCacheKeyValueDepth {
+		b.WriteString("...")
+		return
+	}
+	if v.Type() == typeOfValuePtr && !v.IsNil() && v.CanInterface() {
+		writeCacheKeyValue(b, v.Interface().(*Value).val, visited, depth+1)
+		return
+	}
+	if v.Type() == typeOfTime && v.CanInterface() {
+		b.WriteString(v.Interface().(time.Time).Format(time.RFC3339Nano))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Complex64, reflect.Complex128:
+		b.WriteString(strconv.FormatComplex(v.Complex(), 'g', -1, 128))
+	case reflect.String:
+		b.WriteString(strconv.Quote(v.String()))
+	case reflect.Interface:
+		writeCacheKeyValue(b, v.Elem(), visited, depth+1)
+	case reflect.Ptr:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		if visited[v.Pointer()] {
+			b.WriteString("<cycle>")
+			return
+		}
+		visited[v.Pointer()] = true
+		defer delete(visited, v.Pointer())
+		b.WriteString("&")
+		writeCacheKeyValue(b, v.Elem(), visited, depth+1)
+	case reflect.Slice, reflect.Array:
+		b.WriteString(v.Type().String())
+		b.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			writeCacheKeyValue(b, v.Index(i), visited, depth+1)
+		}
+		b.WriteString("]")
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var entry strings.Builder
+			writeCacheKeyValue(&entry, iter.Key(), visited, depth+1)
+			entry.WriteString(":")
+			writeCacheKeyValue(&entry, iter.Value(), visited, depth+1)
+			entries = append(entries, entry.String())
+		}
+		sort.Strings(entries)
+		b.WriteString(v.Type().String())
+		b.WriteString("{")
+		b.WriteString(strings.Join(entries, ","))
+		b.WriteString("}")
+	case reflect.Struct:
+		b.WriteString(v.Type().String())
+		b.WriteString("{")
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(v.Type().Field(i).Name)
+			b.WriteString(":")
+			writeCacheKeyValue(b, v.Field(i), visited, depth+1)
+		}
+		b.WriteString("}")
+	default:
+		// functions, channels and unsafe pointers are identified by their types only
+		b.WriteString(v.Type().String())
+	}
+}
+
+// isFuncValue reports whether the value is a function, which is not a part of the cache key.
+func isFuncValue(v any) bool {
+	if val, ok := v.(*Value); ok {
+		v = val.Interface()
+	}
+	return v != nil && reflect.TypeOf(v).Kind() == reflect.Func
+}
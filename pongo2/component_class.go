@@ -0,0 +1,120 @@
+package pongo2
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ClassComponent is a component defined by a Go type.
+// A new instance is created for each rendering of the component, so the instance can hold the state of the rendering.
+//
+// The exported methods of the type, except the methods of this interface, are callable from the component template:
+//
+//	type Alert struct {
+//		Type string `pongo2:"type"`
+//	}
+//
+//	func (a *Alert) Props() []*pongo2.PropSpec {
+//		return []*pongo2.PropSpec{{Name: "type", Type: pongo2.PropTypeString, Default: "info"}}
+//	}
+//
+//	func (a *Alert) Setup(ctx *pongo2.ComponentExecutionContext) error {
+//		return ctx.BindProps(a)
+//	}
+//
+//	func (a *Alert) Template() string {
+//		return "components/alert"
+//	}
+//
+//	func (a *Alert) IsError() bool {
+//		return a.Type == "error"
+//	}
+//
+// In the template "components/alert", the method is called like: {% if IsError() %}...{% endif %}
+type ClassComponent interface {
+	// Props returns the props of the component.
+	Props() []*PropSpec
+	// Setup is called before rendering the component.
+	Setup(ctx *ComponentExecutionContext) error
+	// Template returns the template file of the component.
+	Template() string
+}
+
+// ShouldRenderer is an optional interface for ClassComponent.
+// If ShouldRender returns false, the component renders nothing.
+type ShouldRenderer interface {
+	ShouldRender(ctx *ComponentExecutionContext) bool
+}
+
+// ClassComponentDefinition defines a class component with its name.
+type ClassComponentDefinition struct {
+	Name string
+	// New creates a new instance of the component.
+	New func() ClassComponent
+}
+
+func (set *componentSet) RegisterClassComponent(def *ClassComponentDefinition) error {
+	comp, err := def.component()
+	if err != nil {
+		return err
+	}
+	return set.register(comp)
+}
+
+// component converts the definition to the internal representation.
+// The props and the template are read from an instance at the registration.
+func (def *ClassComponentDefinition) component() (*component, error) {
+	if def.New == nil {
+		return nil, fmt.Errorf("class component '%s' needs New", def.Name)
+	}
+	instance := def.New()
+	return &component{
+		Name:           def.Name,
+		TemplateFile:   instance.Template(),
+		TemplateString: "",
+		Props:          mergePropSpecs(nil, instance.Props()),
+		Setup:          nil,
+		New:            def.New,
+		Kind:           ComponentKindClass,
+	}, nil
+}
+
+// classComponentMethods are the methods that are not callable from the component template.
+var classComponentMethods = map[string]bool{
+	"Props":        true,
+	"Setup":        true,
+	"Template":     true,
+	"ShouldRender": true,
+}
+
+// bindClassComponentMethods sets the exported methods of the instance into the context.
+// It does not override the existing data like props.
+// With a sandbox policy, the methods not in AllowedMethods are bound to functions that return the error of the policy.
+func bindClassComponentMethods(instance ClassComponent, data Context, sandbox *SandboxPolicy) {
+	v := reflect.ValueOf(instance)
+	t := v.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		name := t.Method(i).Name
+		if classComponentMethods[name] {
+			continue
+		}
+		if _, ok := data[name]; ok {
+			continue
+		}
+		if sandbox != nil && !sandbox.allowlistsMethod(t, name) {
+			data[name] = methodNotAllowed(t, name)
+			continue
+		}
+		data[name] = v.Method(i).Interface()
+	}
+}
+
+// methodNotAllowed returns a function that returns the error of the sandbox policy for the method of the type.
+func methodNotAllowed(t reflect.Type, method string) func(...*Value) (*Value, error) {
+	return func(...*Value) (*Value, error) {
+		return nil, &SandboxError{
+			Violation: SandboxMethodNotAllowed,
+			Message:   fmt.Sprintf("calling the method '%s' of %s is not allowed", method, t.String()),
+		}
+	}
+}
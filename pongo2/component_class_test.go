@@ -0,0 +1,68 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+type testAlertComponent struct {
+	Type    string `pongo2:"type"`
+	Message string `pongo2:"message"`
+}
+
+func (c *testAlertComponent) Props() []*PropSpec {
+	return []*PropSpec{
+		{Name: "type", Type: PropTypeString, Default: "info"},
+		{Name: "message", Type: PropTypeString, Required: true},
+	}
+}
+
+func (c *testAlertComponent) Setup(ctx *ComponentExecutionContext) error {
+	return ctx.BindProps(c)
+}
+
+func (c *testAlertComponent) Template() string {
+	return "alert.html"
+}
+
+func (c *testAlertComponent) ShouldRender(ctx *ComponentExecutionContext) bool {
+	return c.Message != ""
+}
+
+func (c *testAlertComponent) IsError() bool {
+	return c.Type == "error"
+}
+
+func (c *testAlertComponent) Upper(s string) string {
+	return "[" + s + "]"
+}
+
+func TestClassComponent(t *testing.T) {
+	set := NewSet("test", NewFSLoader(fstest.MapFS{
+		"alert.html": {Data: []byte(`<div {{ attributes }}>{% if IsError() %}!{% endif %}{{ Upper(message) }}:{{ type }}</div>`)},
+	}))
+	set.ComponentSet.RegisterClassComponent(&ClassComponentDefinition{
+		Name: "alert",
+		New: func() ClassComponent {
+			return &testAlertComponent{}
+		},
+	})
+
+	t.Run("render with methods", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "alert" withAttrs "message"="a" "type"="error" "class"="x" %}{% endcomponent %}{% component "alert" withAttrs "message"="b" %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<div class="x">![a]:error</div><div >[b]:info</div>`, out)
+	})
+
+	t.Run("should render", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "alert" withAttrs "message"=msg %}{% endcomponent %}`, Context{"msg": ""})
+		assert.NoError(t, err)
+		assert.Equal(t, "", out)
+	})
+
+	t.Run("props", func(t *testing.T) {
+		_, err := set.FromString(`{% component "alert" %}{% endcomponent %}`)
+		assert.ErrorContains(t, err, "component 'alert': missing required prop 'message'")
+	})
+}
@@ -0,0 +1,101 @@
+package pongo2
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// ComponentLibrary is a distributable set of components with its own templates, registered under a namespace.
+// The components of the library are used with the namespace, like: <x-ui::button>
+//
+// The templates of the library are loaded from FS with the namespace, like "ui::components/button.html".
+// It requires a NamespaceLoader that has the FS of the library.
+type ComponentLibrary struct {
+	// Namespace is the namespace of the library, like "ui".
+	Namespace string
+	// FS is a file system that contains the templates of the library.
+	FS fs.FS
+	// Dir is a directory in FS whose template files are registered as anonymous components.
+	// If it is empty, no anonymous components are registered.
+	Dir string
+	// Components are the components of the library.
+	// The names and the template files, including the ones returned by ResolveTemplate, are relative to the namespace and FS.
+	Components []*Component
+	// InlineComponents are the inline components of the library.
+	InlineComponents []*InlineComponent
+	// ClassComponents are the class components of the library.
+	// The template files returned by the components are relative to FS.
+	ClassComponents []*ClassComponentDefinition
+	// Assets is an optional file system that contains static assets of the library, like CSS and JavaScript.
+	Assets fs.FS
+}
+
+// namespaced returns the name in the namespace of the library.
+func (lib *ComponentLibrary) namespaced(name string) string {
+	return lib.Namespace + NamespaceSeparator + name
+}
+
+// RegisterComponentLibrary registers all components of the library under the namespace of the library.
+func (set *componentSet) RegisterComponentLibrary(lib *ComponentLibrary) error {
+	if lib.Namespace == "" {
+		return fmt.Errorf("component library needs a namespace")
+	}
+
+	if lib.Dir != "" {
+		if lib.FS == nil {
+			return fmt.Errorf("component library '%s' needs FS to register the directory '%s'", lib.Namespace, lib.Dir)
+		}
+		err := set.walkComponentsDirectory(lib.FS, lib.Dir, func(name, templateFile string) error {
+			return set.register(&component{
+				Name:          lib.namespaced(name),
+				TemplateFile:  lib.namespaced(templateFile),
+				Kind:          ComponentKindAnonymous,
+				FromDirectory: true,
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, comp := range lib.InlineComponents {
+		if err := set.register(lib.component(comp.component())); err != nil {
+			return err
+		}
+	}
+	for _, comp := range lib.Components {
+		if err := set.register(lib.component(comp.component())); err != nil {
+			return err
+		}
+	}
+	for _, def := range lib.ClassComponents {
+		comp, err := def.component()
+		if err != nil {
+			return err
+		}
+		if err := set.register(lib.component(comp)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// component puts the component in the namespace of the library.
+// The template files, including the ones chosen by ResolveTemplate, are loaded from FS.
+func (lib *ComponentLibrary) component(comp *component) *component {
+	comp.Name = lib.namespaced(comp.Name)
+	if comp.TemplateFile != "" {
+		comp.TemplateFile = lib.namespaced(comp.TemplateFile)
+	}
+	if resolve := comp.ResolveTemplate; resolve != nil {
+		comp.ResolveTemplate = func(ctx *ComponentExecutionContext) (string, error) {
+			templateFile, err := resolve(ctx)
+			if err != nil || templateFile == "" {
+				return templateFile, err
+			}
+			return lib.namespaced(templateFile), nil
+		}
+	}
+	return comp
+}
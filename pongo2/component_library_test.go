@@ -0,0 +1,117 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestComponentLibrary(t *testing.T) {
+	libFS := fstest.MapFS{
+		"components/button.html":      {Data: []byte(`<button {{ attributes }}>{{ slot }}</button>`)},
+		"components/forms/input.html": {Data: []byte(`<input>{% include "label.html" %}`)},
+		"components/forms/label.html": {Data: []byte(`<label>`)},
+		"templates/card.html":         {Data: []byte(`<div class="card">{{ title }}</div>`)},
+	}
+	appFS := fstest.MapFS{
+		"vendor/ui/components/button.html": {Data: []byte(`<button class="app">{{ slot }}</button>`)},
+	}
+
+	newSet := func(overrideDir string) *TemplateSet {
+		loader := NewNamespaceLoader(NewFSLoader(appFS), overrideDir)
+		loader.AddNamespace("ui", libFS)
+		set := NewSet("test", NewOmitExtensionLoader(loader, ".html"))
+		set.ComponentSet.DefaultTemplateFileExtension = ".html"
+		err := set.ComponentSet.RegisterComponentLibrary(&ComponentLibrary{
+			Namespace: "ui",
+			FS:        libFS,
+			Dir:       "components",
+			Components: []*Component{
+				{Name: "card", TemplateFile: "templates/card", Props: []string{"title"}},
+			},
+			InlineComponents: []*InlineComponent{
+				{Name: "badge", TemplateString: `<span>{{ slot }}</span>`},
+			},
+		})
+		assert.NoError(t, err)
+		return set
+	}
+
+	t.Run("render library components", func(t *testing.T) {
+		set := newSet("")
+		out, err := set.RenderTemplateString(`{% component "ui::button" withAttrs "type"="submit" %}Save{% endcomponent %}{% component "ui::forms.input" %}{% endcomponent %}{% component "ui::card" withAttrs "title"="T" %}{% endcomponent %}{% component "ui::badge" %}b{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<button type="submit">Save</button><input><label><div class="card">T</div><span>b</span>`, out)
+	})
+
+	t.Run("override library templates", func(t *testing.T) {
+		set := newSet("vendor")
+		out, err := set.RenderTemplateString(`{% component "ui::button" %}Save{% endcomponent %}{% component "ui::card" withAttrs "title"="T" %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<button class="app">Save</button><div class="card">T</div>`, out)
+	})
+
+	t.Run("unknown namespace", func(t *testing.T) {
+		set := newSet("")
+		_, err := set.FromFile("admin::index")
+		assert.ErrorContains(t, err, "unable to resolve template")
+	})
+
+	t.Run("component options", func(t *testing.T) {
+		optionsFS := fstest.MapFS{
+			"templates/panel.html":      {Data: []byte(`<div>{{ color }}:{{ render() }}|{{ slot }}</div>`)},
+			"templates/panel-wide.html": {Data: []byte(`<div class="wide">{{ color }}:{{ render() }}|{{ slot }}</div>`)},
+		}
+		loader := NewNamespaceLoader(&DummyLoader{}, "")
+		loader.AddNamespace("ui", optionsFS)
+		set := NewSet("test", NewOmitExtensionLoader(loader, ".html"))
+		renders := 0
+		render := func() int {
+			renders++
+			return renders
+		}
+		err := set.ComponentSet.RegisterComponentLibrary(&ComponentLibrary{
+			Namespace: "ui",
+			FS:        optionsFS,
+			Components: []*Component{
+				{
+					Name:         "panel",
+					TemplateFile: "templates/panel",
+					Setup: func(ctx *ComponentExecutionContext) error {
+						ctx.Set("color", "dark")
+						ctx.Set("render", render)
+						return nil
+					},
+					CacheTTL: time.Minute,
+					ResolveTemplate: func(ctx *ComponentExecutionContext) (string, error) {
+						if ctx.Attributes().Has("wide") {
+							return "templates/panel-wide", nil
+						}
+						return "", nil
+					},
+					Provide: []string{"color"},
+				},
+			},
+			InlineComponents: []*InlineComponent{
+				{Name: "swatch", TemplateString: `[{{ color }}]`, Aware: map[string]any{"color": "none"}},
+			},
+		})
+		assert.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			out, err := set.RenderTemplateString(`{% component "ui::panel" %}{% component "ui::swatch" %}{% endcomponent %}{% endcomponent %}`, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, `<div>dark:1|[dark]</div>`, out)
+		}
+		out, err := set.RenderTemplateString(`{% component "ui::panel" withAttrs "wide"=true %}{% component "ui::swatch" %}{% endcomponent %}{% endcomponent %}{% component "ui::swatch" %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<div class="wide">dark:2|[dark]</div>[none]`, out)
+	})
+
+	t.Run("namespace is required", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		err := set.ComponentSet.RegisterComponentLibrary(&ComponentLibrary{FS: libFS, Dir: "components"})
+		assert.ErrorContains(t, err, "component library needs a namespace")
+	})
+}
@@ -0,0 +1,183 @@
+package pongo2
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PropType represents the type of component prop.
+type PropType int
+
+const (
+	// PropTypeAny accepts any value without conversion.
+	PropTypeAny PropType = iota
+	PropTypeString
+	PropTypeInt
+	PropTypeFloat
+	PropTypeBool
+	PropTypeSlice
+	PropTypeMap
+	PropTypeStruct
+)
+
+var propTypeNames = map[PropType]string{
+	PropTypeAny:    "any",
+	PropTypeString: "string",
+	PropTypeInt:    "int",
+	PropTypeFloat:  "float",
+	PropTypeBool:   "bool",
+	PropTypeSlice:  "slice",
+	PropTypeMap:    "map",
+	PropTypeStruct: "struct",
+}
+
+func (t PropType) String() string {
+	if name, ok := propTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// parsePropType converts a type name used in the props tag (e.g. "string", "int") into a PropType.
+func parsePropType(name string) (PropType, bool) {
+	for t, n := range propTypeNames {
+		if n == name {
+			return t, true
+		}
+	}
+	return PropTypeAny, false
+}
+
+// PropSpec declares a component prop with its type, whether it is required, a default value and an optional validator.
+type PropSpec struct {
+	// Name is the name of the prop.
+	Name string
+	// Type is the type of the prop. The passed value is converted to this type if possible.
+	// For example, a string "3" passed by an HTML attribute is converted to the int 3 for PropTypeInt.
+	Type PropType
+	// Required makes the prop mandatory. A component called without a required prop fails to parse.
+	Required bool
+	// Default is used when the prop is not passed.
+	Default any
+	// Validator is an optional function to validate the value after the type conversion.
+	Validator func(v *Value) error
+}
+
+// check converts the value to the type of the prop and validates it.
+func (spec *PropSpec) check(v *Value) (*Value, error) {
+	if v == nil || v.IsNil() {
+		if spec.Required {
+			return nil, fmt.Errorf("prop '%s' is required but got nil", spec.Name)
+		}
+		return v, nil
+	}
+
+	converted, err := spec.convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Validator != nil {
+		if err := spec.Validator(converted); err != nil {
+			return nil, fmt.Errorf("prop '%s' is invalid: %w", spec.Name, err)
+		}
+	}
+	return converted, nil
+}
+
+func (spec *PropSpec) convert(v *Value) (*Value, error) {
+	kind := v.getResolvedValue().Kind()
+
+	switch spec.Type {
+	case PropTypeAny:
+		return v, nil
+	case PropTypeString:
+		if v.IsString() {
+			return v, nil
+		}
+	case PropTypeInt:
+		if v.IsInteger() {
+			return v, nil
+		}
+		if v.IsString() {
+			if i, err := strconv.Atoi(strings.TrimSpace(v.String())); err == nil {
+				return AsValue(i), nil
+			}
+		}
+	case PropTypeFloat:
+		if v.IsNumber() {
+			return AsValue(v.Float()), nil
+		}
+		if v.IsString() {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64); err == nil {
+				return AsValue(f), nil
+			}
+		}
+	case PropTypeBool:
+		if v.IsBool() {
+			return v, nil
+		}
+		if v.IsString() {
+			if b, err := strconv.ParseBool(strings.TrimSpace(v.String())); err == nil {
+				return AsValue(b), nil
+			}
+		}
+	case PropTypeSlice:
+		if kind == reflect.Slice || kind == reflect.Array {
+			return v, nil
+		}
+	case PropTypeMap:
+		if kind == reflect.Map {
+			return v, nil
+		}
+	case PropTypeStruct:
+		if kind == reflect.Struct {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("prop '%s' must be of type %s, got %s (%q)", spec.Name, spec.Type, kind, v.String())
+}
+
+// literalValue returns the value of the expression if it is a literal without filters.
+// It is used to check props at parse time.
+func literalValue(expr IEvaluator) (*Value, bool) {
+	if fv, ok := expr.(*nodeFilteredVariable); ok {
+		if len(fv.filterChain) > 0 {
+			return nil, false
+		}
+		expr = fv.resolver
+	}
+
+	switch e := expr.(type) {
+	case *stringResolver:
+		return AsValue(e.val), true
+	case *intResolver:
+		return AsValue(e.val), true
+	case *floatResolver:
+		return AsValue(e.val), true
+	case *boolResolver:
+		return AsValue(e.val), true
+	}
+	return nil, false
+}
+
+// mergePropSpecs merges plain prop names and prop specs into a single list.
+// The names that are not declared by the specs are treated as untyped optional props.
+func mergePropSpecs(names []string, specs []*PropSpec) []*PropSpec {
+	merged := make([]*PropSpec, 0, len(names)+len(specs))
+	declared := make(map[string]bool)
+	for _, spec := range specs {
+		declared[spec.Name] = true
+		merged = append(merged, spec)
+	}
+	for _, name := range names {
+		if !declared[name] {
+			declared[name] = true
+			merged = append(merged, &PropSpec{Name: name})
+		}
+	}
+	return merged
+}
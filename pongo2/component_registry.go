@@ -0,0 +1,166 @@
+package pongo2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ComponentKind is the kind of registered component.
+type ComponentKind string
+
+const (
+	ComponentKindAnonymous ComponentKind = "anonymous"
+	ComponentKindInline    ComponentKind = "inline"
+	ComponentKindHeadless  ComponentKind = "headless"
+	ComponentKindComponent ComponentKind = "component"
+	ComponentKindClass     ComponentKind = "class"
+)
+
+// precedence returns the precedence of the component for the registrations with the same name.
+//
+// The precedence rules are:
+//   - The components defined in Go (component, inline, headless and class) take precedence over anonymous components.
+//   - The anonymous components registered explicitly take precedence over the ones registered by scanning a directory.
+//
+// A registration with a lower precedence is ignored, and a registration with the same precedence replaces
+// the previous one with a warning.
+func (comp *component) precedence() int {
+	switch {
+	case comp.Kind == ComponentKindAnonymous && comp.FromDirectory:
+		return 0
+	case comp.Kind == ComponentKindAnonymous:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (comp *component) description() string {
+	if comp.FromDirectory {
+		return fmt.Sprintf("%s component from the directory (%s)", comp.Kind, comp.TemplateFile)
+	}
+	if comp.TemplateFile != "" {
+		return fmt.Sprintf("%s component (%s)", comp.Kind, comp.TemplateFile)
+	}
+	return fmt.Sprintf("%s component", comp.Kind)
+}
+
+// validate returns an error if the component can't be rendered.
+func (comp *component) validate() error {
+	if comp.Name == "" {
+		return fmt.Errorf("component name must not be empty")
+	}
+	if strings.IndexFunc(comp.Name, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("invalid component name '%s': it must not contain whitespace", comp.Name)
+	}
+	if comp.TemplateFile == "" && comp.TemplateString == "" && comp.ResolveTemplate == nil {
+		return fmt.Errorf("component '%s' has no template", comp.Name)
+	}
+	return nil
+}
+
+// register registers the component. It returns an error if the component is invalid.
+// The components with the same name are resolved by the precedence.
+func (set *componentSet) register(comp *component) error {
+	if err := comp.validate(); err != nil {
+		return err
+	}
+
+	if existing, ok := set.components[comp.Name]; ok {
+		for _, c := range append([]*component{existing}, existing.shadowed...) {
+			if comp.precedence() == c.precedence() {
+				logger.Printf("[warning] component '%s' is already registered as %s, and it is replaced with %s",
+					comp.Name, c.description(), comp.description())
+				break
+			}
+		}
+		if comp.precedence() < existing.precedence() {
+			// The existing component shadows the new one.
+			existing.shadowed = append(existing.shadowed, comp)
+			return nil
+		}
+		comp.shadowed = append(existing.shadowed, existing)
+	}
+
+	set.components[comp.Name] = comp
+
+	// drop the cached template of the previous component with the same name
+	set.templatesMutex.Lock()
+	delete(set.templates, comp.Name)
+	set.templatesMutex.Unlock()
+	return nil
+}
+
+// ComponentInfo describes a registered component.
+type ComponentInfo struct {
+	Name string
+	Kind ComponentKind
+	// TemplateFile is the template file of the component. It is empty for inline and headless components.
+	TemplateFile string
+	// FromDirectory is true if the component is registered by scanning a components directory.
+	FromDirectory bool
+	// Props are the props of the component. The props declared in Go take precedence over the ones declared
+	// by the props tag in the template, like on rendering.
+	Props []*PropSpec
+	// HasSetup is true if the component has a Setup function.
+	HasSetup bool
+	// Shadowed are the registrations with the same name that are hidden by this component.
+	Shadowed []*ComponentInfo
+}
+
+func (set *componentSet) info(comp *component) *ComponentInfo {
+	info := &ComponentInfo{
+		Name:          comp.Name,
+		Kind:          comp.Kind,
+		TemplateFile:  comp.TemplateFile,
+		FromDirectory: comp.FromDirectory,
+		Props:         set.props(comp),
+		HasSetup:      comp.Setup != nil || comp.New != nil,
+	}
+	for _, s := range comp.shadowed {
+		info.Shadowed = append(info.Shadowed, set.info(s))
+	}
+	return info
+}
+
+// props returns the props of the component, parsing the template for the props tag if needed.
+// A template that fails to parse is reported on rendering, so it has no props here.
+func (set *componentSet) props(comp *component) []*PropSpec {
+	if len(comp.Props) > 0 || set.templateSet == nil || (comp.TemplateFile == "" && comp.TemplateString == "") {
+		return comp.Props
+	}
+	var tpl *Template
+	var err error
+	if set.components[comp.Name] == comp {
+		tpl, err = set.templateSet.cachedComponentTemplate(comp)
+	} else {
+		// the templates are cached by the name, which the shadowed components share
+		tpl, err = set.templateSet.parseComponentTemplate(comp)
+	}
+	if err != nil {
+		return nil
+	}
+	return componentProps(comp, tpl)
+}
+
+// Components returns the registered components sorted by name.
+func (set *componentSet) Components() []*ComponentInfo {
+	infos := make([]*ComponentInfo, 0, len(set.components))
+	for _, comp := range set.components {
+		infos = append(infos, set.info(comp))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Component returns the registered component by the name, or nil if it is not registered.
+func (set *componentSet) Component(name string) *ComponentInfo {
+	if comp := set.resolveComponent(name); comp != nil {
+		return set.info(comp)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestComponentRegistry(t *testing.T) {
+	newSet := func() *TemplateSet {
+		fsys := fstest.MapFS{
+			"components/alert.html":  {Data: []byte(`dir alert`)},
+			"components/button.html": {Data: []byte(`dir button`)},
+			// fixtures of the component gallery are not registered as components
+			"components/button.fixtures.json": {Data: []byte(`[]`)},
+			"alert.html":                      {Data: []byte(`anonymous alert`)},
+			"props.html":                      {Data: []byte(`{% props type="info", message %}{{ message }}`)},
+		}
+		set := NewSet("test", NewFSLoader(fsys))
+		set.ComponentSet.TemplateSetFS = fsys
+		set.ComponentSet.DefaultTemplateFileExtension = ".html"
+		return set
+	}
+
+	t.Run("precedence", func(t *testing.T) {
+		set := newSet()
+		cs := set.ComponentSet
+		assert.NoError(t, cs.RegisterTemplateFileComponentsDirectory(&AnonymousComponentsDirectory{Dir: "components"}))
+		assert.NoError(t, cs.RegisterAnonymousComponent(&AnonymousComponent{Name: "alert", TemplateFile: "alert.html"}))
+		assert.NoError(t, cs.RegisterInlineComponent(&InlineComponent{Name: "button", TemplateString: "inline button"}))
+		// a lower precedence registration is ignored
+		assert.NoError(t, cs.RegisterAnonymousComponent(&AnonymousComponent{Name: "button", TemplateFile: "alert.html"}))
+
+		out, err := set.RenderTemplateString(`{% component "alert" %}{% endcomponent %}/{% component "button" %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "anonymous alert/inline button", out)
+
+		button := cs.Component("button")
+		assert.Equal(t, ComponentKindInline, button.Kind)
+		assert.Len(t, button.Shadowed, 2)
+		assert.Equal(t, ComponentKindAnonymous, button.Shadowed[0].Kind)
+		assert.True(t, button.Shadowed[0].FromDirectory)
+		assert.False(t, button.Shadowed[1].FromDirectory)
+		assert.Len(t, cs.Components(), 2)
+	})
+
+	t.Run("duplicates", func(t *testing.T) {
+		set := newSet()
+		cs := set.ComponentSet
+		// the later registration with the same precedence replaces the previous one
+		assert.NoError(t, cs.RegisterComponent(&Component{Name: "alert", TemplateFile: "alert.html"}))
+		assert.NoError(t, cs.RegisterInlineComponent(&InlineComponent{Name: "alert", TemplateString: "inline alert"}))
+
+		assert.NoError(t, cs.RegisterAnonymousComponent(&AnonymousComponent{Name: "button", TemplateFile: "alert.html"}))
+		assert.NoError(t, cs.RegisterAnonymousComponent(&AnonymousComponent{Name: "button", TemplateFile: "components/button.html"}))
+
+		out, err := set.RenderTemplateString(`{% component "alert" %}{% endcomponent %}/{% component "button" %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "inline alert/dir button", out)
+
+		alert := cs.Component("alert")
+		assert.Equal(t, ComponentKindInline, alert.Kind)
+		assert.Len(t, alert.Shadowed, 1)
+		assert.Equal(t, ComponentKindComponent, alert.Shadowed[0].Kind)
+
+		assert.NoError(t, cs.RegisterTemplateFileComponentsDirectory(&AnonymousComponentsDirectory{Dir: "components"}))
+		assert.NoError(t, cs.RegisterTemplateFileComponentsDirectory(&AnonymousComponentsDirectory{Dir: "components"}))
+	})
+
+	t.Run("list components", func(t *testing.T) {
+		set := newSet()
+		cs := set.ComponentSet
+		assert.NoError(t, cs.RegisterComponent(&Component{
+			Name:         "alert",
+			TemplateFile: "alert.html",
+			Props:        []string{"message"},
+			Setup: func(ctx *ComponentExecutionContext) error {
+				return nil
+			},
+		}))
+		assert.NoError(t, cs.RegisterHeadlessComponent(&HeadlessComponent{Name: "headless"}))
+
+		infos := cs.Components()
+		assert.Len(t, infos, 2)
+		assert.Equal(t, "alert", infos[0].Name)
+		assert.Equal(t, ComponentKindComponent, infos[0].Kind)
+		assert.Equal(t, "alert.html", infos[0].TemplateFile)
+		assert.Equal(t, "message", infos[0].Props[0].Name)
+		assert.True(t, infos[0].HasSetup)
+		assert.Equal(t, "headless", infos[1].Name)
+		assert.Equal(t, ComponentKindHeadless, infos[1].Kind)
+		assert.False(t, infos[1].HasSetup)
+		assert.Nil(t, cs.Component("unknown"))
+	})
+
+	t.Run("props declared in the template", func(t *testing.T) {
+		set := newSet()
+		cs := set.ComponentSet
+		assert.NoError(t, cs.RegisterAnonymousComponent(&AnonymousComponent{Name: "props", TemplateFile: "props.html"}))
+		assert.NoError(t, cs.RegisterInlineComponent(&InlineComponent{Name: "inline", TemplateString: `{% props label %}{{ label }}`}))
+		assert.NoError(t, cs.RegisterComponent(&Component{Name: "go", TemplateFile: "props.html", Props: []string{"title"}}))
+
+		props := cs.Component("props").Props
+		if assert.Len(t, props, 2) {
+			assert.Equal(t, "type", props[0].Name)
+			assert.Equal(t, "message", props[1].Name)
+		}
+		assert.Equal(t, "label", cs.Component("inline").Props[0].Name)
+		// the props declared in Go take precedence
+		assert.Len(t, cs.Component("go").Props, 1)
+		assert.Equal(t, "title", cs.Component("go").Props[0].Name)
+	})
+
+	t.Run("invalid registrations", func(t *testing.T) {
+		set := newSet()
+		cs := set.ComponentSet
+		assert.EqualError(t, cs.RegisterInlineComponent(&InlineComponent{TemplateString: "x"}), "component name must not be empty")
+		assert.EqualError(t, cs.RegisterAnonymousComponent(&AnonymousComponent{Name: "my alert", TemplateFile: "alert.html"}), "invalid component name 'my alert': it must not contain whitespace")
+		assert.EqualError(t, cs.RegisterComponent(&Component{Name: "alert"}), "component 'alert' has no template")
+		assert.EqualError(t, cs.RegisterClassComponent(&ClassComponentDefinition{Name: "alert"}), "class component 'alert' needs New")
+		assert.Empty(t, cs.Components())
+	})
+}
@@ -0,0 +1,118 @@
+package pongo2
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Slot is a named slot passed to a component.
+// The slot content is rendered lazily when the component template outputs it for the first time,
+// so a slot that the component never outputs is never rendered.
+//
+// In the component template, a slot is used like the following:
+//
+//	{% if not footer.IsEmpty() %}
+//	  <footer {{ footer.Attributes }}>{{ footer }}</footer>
+//	{% endif %}
+type Slot struct {
+	// Name is the name of the slot.
+	Name string
+	// Attributes are the attributes of the slot tag, like: <x-slot name="footer" class="mt-4">
+	Attributes *Attributes
+
+	node     *tagComponentNode
+	ctx      *ExecutionContext
+	data     Context
+	slot     *componentSlot
+	provided Context
+	rendered *Value
+	err      *Error
+}
+
+// String renders the slot content.
+// The rendered content is cached, so the slot is rendered at most once.
+// An error in rendering is reported after the component template is executed.
+func (s *Slot) String() string {
+	val, err := s.value()
+	if err != nil {
+		return ""
+	}
+	return val.String()
+}
+
+// IsEmpty reports whether the rendered slot content has only whitespace.
+func (s *Slot) IsEmpty() bool {
+	if len(s.slot.args) > 0 {
+		// A scoped slot can not be rendered without its arguments.
+		return false
+	}
+	val, err := s.value()
+	if err != nil {
+		return true
+	}
+	return strings.TrimSpace(val.String()) == ""
+}
+
+// Render renders the slot content with the arguments declared by the slot.
+// Unlike String, the result is not cached.
+func (s *Slot) Render(args ...*Value) (*Value, error) {
+	val, err := s.node.renderSlot(s.ctx, s.data, s.provided, s.slot, args)
+	if err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+func (s *Slot) value() (*Value, *Error) {
+	if s.rendered == nil && s.err == nil {
+		s.rendered, s.err = s.node.renderSlot(s.ctx, s.data, s.provided, s.slot, nil)
+	}
+	return s.rendered, s.err
+}
+
+// renderSlot renders the slot content in the caller's context.
+// The args are bound to the arguments declared by the slot, like: {% slot "row" args item %}
+// The components in the slot can consume the values provided by the component.
+func (node *tagComponentNode) renderSlot(ctx *ExecutionContext, data Context, provided Context, slot *componentSlot, args []*Value) (*Value, *Error) {
+	if len(args) > len(slot.args) {
+		return nil, ctx.Error(fmt.Sprintf("slot '%s' of component '%s' called with too many arguments (%d instead of %d)",
+			slot.Name, node.component.Name, len(args), len(slot.args)), node.position)
+	}
+
+	slotCtx := NewChildExecutionContext(ctx)
+	slotCtx.provided = provided
+	if node.slotData != nil {
+		// expose the component data to the slot
+		if node.slotData.name != "" {
+			slotCtx.Private[node.slotData.name] = data
+		} else {
+			// extract specific parameters directly from the component data
+			for _, key := range node.slotData.keys {
+				if key.alias == "" {
+					slotCtx.Private[key.name] = data[key.name]
+				} else {
+					slotCtx.Private[key.alias] = data[key.name]
+				}
+			}
+		}
+	}
+
+	// bind the slot arguments
+	for i, name := range slot.args {
+		if i < len(args) {
+			slotCtx.Private[name] = args[i]
+		} else {
+			slotCtx.Private[name] = nil
+		}
+	}
+
+	var b bytes.Buffer
+	if err := slot.wrapper.Execute(slotCtx, &b); err != nil {
+		return nil, err
+	}
+	if slot.preserveWhitespace {
+		return AsSafeValue(b.String()), nil
+	}
+	return AsSafeValue(strings.TrimSpace(b.String())), nil
+}
@@ -74,6 +74,16 @@ type ExecutionContext struct {
 	Shared     Context
 
 	echoContext echo.Context
+
+	// render is the state shared across the whole render
+	render *renderState
+
+	// provided are the values provided by the ancestor components
+	provided Context
+
+	// allowUndefined is greater than 0 while evaluating an expression that tolerates undefined variables
+	// in the strict undefined mode, like the operand of the default filter.
+	allowUndefined int
 }
 
 var pongo2MetaContext = Context{
@@ -116,6 +126,9 @@ func NewChildExecutionContext(parent *ExecutionContext) *ExecutionContext {
 		Autoescape: parent.Autoescape,
 
 		echoContext: parent.echoContext,
+
+		render:   parent.render,
+		provided: parent.provided,
 	}
 	newctx.Shared = parent.Shared
 
@@ -125,6 +138,26 @@ func NewChildExecutionContext(parent *ExecutionContext) *ExecutionContext {
 	return newctx
 }
 
+// strictUndefined reports whether resolving an undefined variable is an error.
+func (ctx *ExecutionContext) strictUndefined() bool {
+	return ctx.template.set.StrictUndefined && ctx.allowUndefined == 0
+}
+
+// evaluateAllowUndefined evaluates the expression without the strict undefined check.
+func (ctx *ExecutionContext) evaluateAllowUndefined(expr IEvaluator) (*Value, *Error) {
+	ctx.allowUndefined++
+	defer func() { ctx.allowUndefined-- }()
+	return expr.Evaluate(ctx)
+}
+
+// timezone returns the time zone of the user by TemplateSet.TimezoneKey, or TemplateSet.Location.
+func (ctx *ExecutionContext) timezone() *Value {
+	if tz := ctx.lookupKey(ctx.template.set.TimezoneKey); !tz.IsNil() && tz.String() != "" {
+		return tz
+	}
+	return AsValue(ctx.template.set.Location)
+}
+
 func (ctx *ExecutionContext) Error(msg string, token *Token) *Error {
 	return ctx.OrigError(errors.New(msg), token)
 }
@@ -30,10 +30,16 @@ func MarshalContext(data any) (Context, error) {
 }
 
 func UnmarshalContext(c Context, dst any) error {
+	return unmarshalContext(c, dst, nil)
+}
+
+// unmarshalContext decodes the context into dst. The keys not decoded into dst are recorded in the metadata if it is not nil.
+func unmarshalContext(c Context, dst any, metadata *mapstructure.Metadata) error {
 	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 		TagName:          "pongo2",
 		WeaklyTypedInput: true,
 		Result:           dst,
+		Metadata:         metadata,
 		DecodeHook:       mapstructure.ComposeDecodeHookFunc(valueConvertHook),
 	})
 	if err != nil {
@@ -58,6 +58,11 @@ func (e *Error) Error() string {
 	return s
 }
 
+// Unwrap returns the original error, so that errors.Is and errors.As can inspect it.
+func (e *Error) Unwrap() error {
+	return e.OrigError
+}
+
 // RawLine returns the affected line from the original template, if available.
 func (e *Error) RawLine() (line string, available bool, outErr error) {
 	if e.Line <= 0 || e.Filename == "<string>" {
@@ -7,8 +7,29 @@ import (
 // FilterFunction is the type filter functions must fulfil
 type FilterFunction func(in *Value, param *Value) (out *Value, err *Error)
 
+// FilterFunctionWithArgs is the type of the filter functions that accept multiple positional and keyword arguments,
+// like: {{ price|money("EUR", locale="de") }}
+// The filter can also be called with a single parameter, like: {{ price|money:"EUR" }}
+type FilterFunctionWithArgs func(in *Value, args []*Value, kwargs Kwargs) (out *Value, err *Error)
+
+// contextKwarg is a keyword argument that a builtin filter takes from the context if it is not passed,
+// like the time zone of the date filter.
+type contextKwarg struct {
+	name  string
+	value func(ctx *ExecutionContext) *Value
+}
+
+// registerFilterContextKwargs makes the filter take the keyword arguments from the context.
+// Replacing the filter removes them.
+func (set *TemplateSet) registerFilterContextKwargs(name string, kwargs ...contextKwarg) {
+	set.filterContextKwargs[name] = append(set.filterContextKwargs[name], kwargs...)
+}
+
 // FilterExists returns true if the given filter is already registered
 func (set *TemplateSet) FilterExists(name string) bool {
+	if _, existing := set.filtersWithArgs[name]; existing {
+		return true
+	}
 	_, existing := set.filters[name]
 	return existing
 }
@@ -31,10 +52,34 @@ func (set *TemplateSet) ReplaceFilter(name string, fn FilterFunction) error {
 	if !set.FilterExists(name) {
 		return fmt.Errorf("filter with name '%s' does not exist (therefore cannot be overridden)", name)
 	}
+	delete(set.filtersWithArgs, name)
+	delete(set.filterContextKwargs, name)
 	set.filters[name] = fn
 	return nil
 }
 
+// RegisterFilterWithArgs registers a new filter that accepts multiple positional and keyword arguments.
+// If there's already a filter with the same name, it returns an error.
+func (set *TemplateSet) RegisterFilterWithArgs(name string, fn FilterFunctionWithArgs) error {
+	if set.FilterExists(name) {
+		return fmt.Errorf("filter with name '%s' is already registered", name)
+	}
+	set.filtersWithArgs[name] = fn
+	return nil
+}
+
+// ReplaceFilterWithArgs replaces an already registered filter with a new implementation
+// that accepts multiple positional and keyword arguments.
+func (set *TemplateSet) ReplaceFilterWithArgs(name string, fn FilterFunctionWithArgs) error {
+	if !set.FilterExists(name) {
+		return fmt.Errorf("filter with name '%s' does not exist (therefore cannot be overridden)", name)
+	}
+	delete(set.filters, name)
+	delete(set.filterContextKwargs, name)
+	set.filtersWithArgs[name] = fn
+	return nil
+}
+
 // MustApplyFilter behaves like ApplyFilter, but panics on an error.
 func (set *TemplateSet) MustApplyFilter(name string, value *Value, param *Value) *Value {
 	val, err := set.ApplyFilter(name, value, param)
@@ -47,6 +92,19 @@ func (set *TemplateSet) MustApplyFilter(name string, value *Value, param *Value)
 // ApplyFilter applies a filter to a given value using the given parameters.
 // Returns a *pongo2.Value or an error.
 func (set *TemplateSet) ApplyFilter(name string, value *Value, param *Value) (*Value, *Error) {
+	// Make sure param is a *Value
+	if param == nil {
+		param = AsValue(nil)
+	}
+
+	if fn, existing := set.filtersWithArgs[name]; existing {
+		var args []*Value
+		if !param.IsNil() {
+			args = []*Value{param}
+		}
+		return fn(value, args, Kwargs{})
+	}
+
 	fn, existing := set.filters[name]
 	if !existing {
 		return nil, &Error{
@@ -55,12 +113,31 @@ func (set *TemplateSet) ApplyFilter(name string, value *Value, param *Value) (*V
 		}
 	}
 
-	// Make sure param is a *Value
-	if param == nil {
-		param = AsValue(nil)
+	return fn(value, param)
+}
+
+// ApplyFilterWithArgs applies a filter to a given value using the given positional and keyword arguments.
+// A filter registered by RegisterFilter accepts at most one positional argument and no keyword arguments.
+func (set *TemplateSet) ApplyFilterWithArgs(name string, value *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+	if fn, existing := set.filtersWithArgs[name]; existing {
+		if kwargs == nil {
+			kwargs = Kwargs{}
+		}
+		return fn(value, args, kwargs)
 	}
 
-	return fn(value, param)
+	if _, existing := set.filters[name]; existing && (len(args) > 1 || len(kwargs) > 0) {
+		return nil, &Error{
+			Sender:    "applyfilter",
+			OrigError: fmt.Errorf("filter '%s' accepts at most one argument and no keyword arguments", name),
+		}
+	}
+
+	param := AsValue(nil)
+	if len(args) > 0 {
+		param = args[0]
+	}
+	return set.ApplyFilter(name, value, param)
 }
 
 type filterCall struct {
@@ -69,30 +146,73 @@ type filterCall struct {
 	name      string
 	parameter IEvaluator
 
-	filterFunc FilterFunction
+	// args and kwargs are the arguments of the call syntax, like: money("EUR", locale="de")
+	args   []functionCallArgument
+	kwargs []*keywordArgument
+
+	filterFunc         FilterFunction
+	filterFuncWithArgs FilterFunctionWithArgs
+	// contextKwargs are the keyword arguments that the filter takes from the context
+	contextKwargs []contextKwarg
 }
 
 func (fc *filterCall) Execute(v *Value, ctx *ExecutionContext) (*Value, *Error) {
-	var param *Value
+	var filteredValue *Value
 	var err *Error
 
-	if fc.parameter != nil {
-		param, err = fc.parameter.Evaluate(ctx)
+	if fc.filterFuncWithArgs != nil {
+		args := make([]*Value, 0, len(fc.args)+1)
+		if fc.parameter != nil {
+			param, err := fc.parameter.Evaluate(ctx)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, param)
+		}
+		for _, arg := range fc.args {
+			val, err := arg.Evaluate(ctx)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, val)
+		}
+		kwargs := make(Kwargs, len(fc.kwargs)+1)
+		for _, kwarg := range fc.kwargs {
+			val, err := kwarg.expr.Evaluate(ctx)
+			if err != nil {
+				return nil, err
+			}
+			kwargs[kwarg.name] = val
+		}
+		for _, kwarg := range fc.contextKwargs {
+			if !kwargs.Has(kwarg.name) {
+				if val := kwarg.value(ctx); val != nil && !val.IsNil() {
+					kwargs[kwarg.name] = val
+				}
+			}
+		}
+
+		filteredValue, err = fc.filterFuncWithArgs(v, args, kwargs)
+	} else {
+		param := AsValue(nil)
+		if fc.parameter != nil {
+			param, err = fc.parameter.Evaluate(ctx)
+		} else if len(fc.args) > 0 {
+			param, err = fc.args[0].Evaluate(ctx)
+		}
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		param = AsValue(nil)
-	}
 
-	filteredValue, err := fc.filterFunc(v, param)
+		filteredValue, err = fc.filterFunc(v, param)
+	}
 	if err != nil {
 		return nil, err.updateFromTokenIfNeeded(ctx.template, fc.token)
 	}
 	return filteredValue, nil
 }
 
-// Filter = IDENT | IDENT ":" FilterArg | IDENT "|" Filter
+// Filter = IDENT | IDENT ":" FilterArg | IDENT "(" Arguments ")" | IDENT "|" Filter
 func (p *Parser) parseFilter() (*filterCall, *Error) {
 	identToken := p.MatchType(TokenIdentifier)
 
@@ -107,12 +227,28 @@ func (p *Parser) parseFilter() (*filterCall, *Error) {
 	}
 
 	// Get the appropriate filter function and bind it
-	filterFn, exists := p.template.set.filters[identToken.Val]
-	if !exists {
+	if filterFn, exists := p.template.set.filtersWithArgs[identToken.Val]; exists {
+		filter.filterFuncWithArgs = filterFn
+		filter.contextKwargs = p.template.set.filterContextKwargs[identToken.Val]
+	} else if filterFn, exists := p.template.set.filters[identToken.Val]; exists {
+		filter.filterFunc = filterFn
+	} else {
 		return nil, p.Error(fmt.Sprintf("Filter '%s' does not exist.", identToken.Val), identToken)
 	}
 
-	filter.filterFunc = filterFn
+	// Check for the call syntax: IDENT "(" Arguments ")"
+	if p.Match(TokenSymbol, "(") != nil {
+		args, kwargs, err := p.parseCallArguments()
+		if err != nil {
+			return nil, err
+		}
+		if filter.filterFunc != nil && (len(args) > 1 || len(kwargs) > 0) {
+			return nil, p.Error(fmt.Sprintf("Filter '%s' accepts at most one argument and no keyword arguments.", identToken.Val), identToken)
+		}
+		filter.args = args
+		filter.kwargs = kwargs
+		return filter, nil
+	}
 
 	// Check for filter-argument (2 tokens needed: ':' ARG)
 	if p.Match(TokenSymbol, ":") != nil {
@@ -3,10 +3,7 @@ package pongo2
 /* Filters that are provided through github.com/flosch/pongo2-addons:
    ------------------------------------------------------------------
 
-   filesizeformat
    slugify
-   timesince
-   timeuntil
 
    Filters that won't be added:
    ----------------------------
@@ -52,7 +49,6 @@ func init() {
 	RegisterFilter("capfirst", filterCapfirst)
 	RegisterFilter("center", filterCenter)
 	RegisterFilter("cut", filterCut)
-	RegisterFilter("date", filterDate)
 	RegisterFilter("default", filterDefault)
 	RegisterFilter("default_if_none", filterDefaultIfNone)
 	RegisterFilter("divisibleby", filterDivisibleby)
@@ -79,7 +75,6 @@ func init() {
 	RegisterFilter("split", filterSplit)
 	RegisterFilter("stringformat", filterStringformat)
 	RegisterFilter("striptags", filterStriptags)
-	RegisterFilter("time", filterDate) // time uses filterDate (same golang-format)
 	RegisterFilter("title", filterTitle)
 	RegisterFilter("truncatechars", filterTruncatechars)
 	RegisterFilter("truncatechars_html", filterTruncatecharsHTML)
@@ -590,17 +585,6 @@ func filterCenter(in *Value, param *Value) (*Value, *Error) {
 		in.String(), strings.Repeat(" ", right))), nil
 }
 
-func filterDate(in *Value, param *Value) (*Value, *Error) {
-	t, isTime := in.Interface().(time.Time)
-	if !isTime {
-		return nil, &Error{
-			Sender:    "filter:date",
-			OrigError: errors.New("filter input argument must be of type 'time.Time'"),
-		}
-	}
-	return AsValue(t.Format(param.String())), nil
-}
-
 func filterFloat(in *Value, param *Value) (*Value, *Error) {
 	return AsValue(in.Float()), nil
 }
@@ -0,0 +1,559 @@
+package pongo2
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// date and time filters
+// Usage:
+// {{ created|date:"2006-01-02 15:04" }}                          Go layout
+// {{ created|date("%Y-%m-%d %H:%M", style="strftime") }}         strftime
+// {{ created|date("N j, Y, P", style="django", tz="Asia/Tokyo") }} Django format characters
+// {{ created|time("H:i", style="django", tz=user.Timezone) }}
+// {{ created|timesince }} {{ deadline|timeuntil }} {{ created|naturaltime }}
+//
+// The format is a Go layout unless the style keyword argument is "strftime" or "django".
+// The time zone is a name of the IANA Time Zone database or a *time.Location.
+// If the tz keyword argument is not passed, the time zone of TemplateSet.TimezoneKey or TemplateSet.Location is used.
+//
+// The names of the months and the weekdays in the strftime and Django formats, and the outputs of
+// timesince, timeuntil and naturaltime are translated by TemplateSet.Catalog in the locale of TemplateSet.LocaleKey,
+// or by the catalog and the locale keyword arguments. A Go layout is formatted by time.Format, so its names are English.
+
+// timezoneKwarg is the tz keyword argument of the date and time filters taken from the context.
+var timezoneKwarg = contextKwarg{name: "tz", value: (*ExecutionContext).timezone}
+
+// catalogKwarg is the catalog keyword argument of the date and time filters taken from the template set.
+var catalogKwarg = contextKwarg{name: "catalog", value: func(ctx *ExecutionContext) *Value {
+	if catalog := ctx.template.set.Catalog; catalog != nil {
+		return AsValue(catalog)
+	}
+	return nil
+}}
+
+// timeNow returns the current time. It is replaced in tests.
+var timeNow = time.Now
+
+const (
+	defaultDateFormat = "2006-01-02"
+	defaultTimeFormat = "15:04"
+)
+
+func filterDateFunc(name string, defaultFormat string) FilterFunctionWithArgs {
+	return func(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+		t, ok, err := filterTimeInput(name, in)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return AsValue(""), nil
+		}
+
+		format := defaultFormat
+		if len(args) > 0 && args[0].String() != "" {
+			format = args[0].String()
+		}
+		tz := kwargs.Get("tz")
+		if len(args) > 1 {
+			tz = args[1]
+		}
+		t, err = filterTimeIn(name, t, tz)
+		if err != nil {
+			return nil, err
+		}
+		out, err2 := formatTime(t, format, kwargs.Get("style").String(), filterTranslator(kwargs))
+		if err2 != nil {
+			return nil, &Error{
+				Sender:    "filter:" + name,
+				OrigError: err2,
+			}
+		}
+		return AsValue(out), nil
+	}
+}
+
+func filterTimesince(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+	t, ok, err := filterTimeInput("timesince", in)
+	if err != nil || !ok {
+		return AsValue(""), err
+	}
+	now, err := filterTimeArg("timesince", args)
+	if err != nil {
+		return nil, err
+	}
+	return AsValue(timesince(t, now, filterTranslator(kwargs))), nil
+}
+
+func filterTimeuntil(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+	t, ok, err := filterTimeInput("timeuntil", in)
+	if err != nil || !ok {
+		return AsValue(""), err
+	}
+	now, err := filterTimeArg("timeuntil", args)
+	if err != nil {
+		return nil, err
+	}
+	return AsValue(timesince(now, t, filterTranslator(kwargs))), nil
+}
+
+func filterNaturaltime(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+	t, ok, err := filterTimeInput("naturaltime", in)
+	if err != nil || !ok {
+		return AsValue(""), err
+	}
+	now, err := filterTimeArg("naturaltime", args)
+	if err != nil {
+		return nil, err
+	}
+	return AsValue(naturaltime(t, now, filterTranslator(kwargs))), nil
+}
+
+// filterTranslator returns the translator for the catalog and the locale keyword arguments.
+func filterTranslator(kwargs Kwargs) messageTranslator {
+	catalog, _ := kwargs.Get("catalog").Interface().(Catalog)
+	return messageTranslator{catalog: catalog, locale: kwargs.Get("locale").String()}
+}
+
+// filterTimeInput returns the time of the filter input. It returns false if the input is nil.
+func filterTimeInput(name string, in *Value) (time.Time, bool, *Error) {
+	switch t := in.Interface().(type) {
+	case time.Time:
+		return t, true, nil
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, false, nil
+		}
+		return *t, true, nil
+	case nil:
+		return time.Time{}, false, nil
+	}
+	return time.Time{}, false, &Error{
+		Sender:    "filter:" + name,
+		OrigError: errors.New("filter input argument must be of type 'time.Time'"),
+	}
+}
+
+// filterTimeArg returns the time to compare the filter input with. It defaults to the current time.
+func filterTimeArg(name string, args []*Value) (time.Time, *Error) {
+	if len(args) == 0 || args[0].IsNil() {
+		return timeNow(), nil
+	}
+	t, ok, err := filterTimeInput(name, args[0])
+	if err != nil || !ok {
+		return time.Time{}, &Error{
+			Sender:    "filter:" + name,
+			OrigError: errors.New("filter argument must be of type 'time.Time'"),
+		}
+	}
+	return t, nil
+}
+
+// filterTimeIn converts the time to the time zone. It returns the time as is if the time zone is nil.
+func filterTimeIn(name string, t time.Time, tz *Value) (time.Time, *Error) {
+	switch loc := tz.Interface().(type) {
+	case nil:
+		return t, nil
+	case *time.Location:
+		if loc == nil {
+			return t, nil
+		}
+		return t.In(loc), nil
+	case time.Location:
+		return t.In(&loc), nil
+	}
+	if tz.String() == "" {
+		return t, nil
+	}
+	loc, err := time.LoadLocation(tz.String())
+	if err != nil {
+		return t, &Error{
+			Sender:    "filter:" + name,
+			OrigError: fmt.Errorf("unknown time zone '%s'", tz.String()),
+		}
+	}
+	return t.In(loc), nil
+}
+
+// formatTime formats the time by a Go layout, a strftime format or Django format characters.
+// The style is "go" (or empty), "strftime" or "django".
+func formatTime(t time.Time, format string, style string, tr messageTranslator) (string, error) {
+	switch style {
+	case "", "go":
+		return t.Format(format), nil
+	case "strftime":
+		return strftime(t, format, tr), nil
+	case "django":
+		return djangoDateFormat(t, format, tr), nil
+	}
+	return "", fmt.Errorf("unknown style '%s', expected 'go', 'strftime' or 'django'", style)
+}
+
+// monthName returns the translated name of the month, like "January", or its abbreviation, like "Jan".
+func monthName(t time.Time, abbr bool, tr messageTranslator) string {
+	name := t.Month().String()
+	if abbr {
+		name = name[:3]
+	}
+	return tr.translate(name)
+}
+
+// weekdayName returns the translated name of the weekday, like "Monday", or its abbreviation, like "Mon".
+func weekdayName(t time.Time, abbr bool, tr messageTranslator) string {
+	name := t.Weekday().String()
+	if abbr {
+		name = name[:3]
+	}
+	return tr.translate(name)
+}
+
+// strftime formats the time by the C strftime directives.
+func strftime(t time.Time, format string, tr messageTranslator) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i == len(format)-1 {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'a':
+			b.WriteString(weekdayName(t, true, tr))
+		case 'A':
+			b.WriteString(weekdayName(t, false, tr))
+		case 'b', 'h':
+			b.WriteString(monthName(t, true, tr))
+		case 'B':
+			b.WriteString(monthName(t, false, tr))
+		case 'c':
+			b.WriteString(weekdayName(t, true, tr) + " " + monthName(t, true, tr) + t.Format(" _2 15:04:05 2006"))
+		case 'C':
+			fmt.Fprintf(&b, "%02d", t.Year()/100)
+		case 'd':
+			b.WriteString(t.Format("02"))
+		case 'D':
+			b.WriteString(t.Format("01/02/06"))
+		case 'e':
+			b.WriteString(t.Format("_2"))
+		case 'f':
+			fmt.Fprintf(&b, "%06d", t.Nanosecond()/1000)
+		case 'F':
+			b.WriteString(t.Format("2006-01-02"))
+		case 'H':
+			b.WriteString(t.Format("15"))
+		case 'I':
+			b.WriteString(t.Format("03"))
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case 'k':
+			fmt.Fprintf(&b, "%2d", t.Hour())
+		case 'l':
+			hour := t.Hour() % 12
+			if hour == 0 {
+				hour = 12
+			}
+			fmt.Fprintf(&b, "%2d", hour)
+		case 'm':
+			b.WriteString(t.Format("01"))
+		case 'M':
+			b.WriteString(t.Format("04"))
+		case 'n':
+			b.WriteByte('\n')
+		case 'p':
+			b.WriteString(tr.translate(t.Format("PM")))
+		case 'R':
+			b.WriteString(t.Format("15:04"))
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'S':
+			b.WriteString(t.Format("05"))
+		case 't':
+			b.WriteByte('\t')
+		case 'T':
+			b.WriteString(t.Format("15:04:05"))
+		case 'u':
+			wd := int(t.Weekday())
+			if wd == 0 {
+				wd = 7
+			}
+			b.WriteString(strconv.Itoa(wd))
+		case 'w':
+			b.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'y':
+			b.WriteString(t.Format("06"))
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'z':
+			b.WriteString(t.Format("-0700"))
+		case 'Z':
+			b.WriteString(t.Format("MST"))
+		case '%':
+			b.WriteByte('%')
+		default:
+			// unknown directives are output as is
+			b.WriteByte('%')
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
+
+var djangoMonthsAP = [...]string{"Jan.", "Feb.", "March", "April", "May", "June", "July", "Aug.", "Sept.", "Oct.", "Nov.", "Dec."}
+
+// djangoDateFormat formats the time by the format characters of the Django date filter.
+// A backslash escapes the next character.
+func djangoDateFormat(t time.Time, format string, tr messageTranslator) string {
+	var b strings.Builder
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch c {
+		case '\\':
+			if i < len(runes)-1 {
+				i++
+				b.WriteRune(runes[i])
+			}
+		case 'a':
+			b.WriteString(djangoMeridiem(t, tr))
+		case 'A':
+			b.WriteString(tr.translate(t.Format("PM")))
+		case 'b':
+			b.WriteString(strings.ToLower(monthName(t, true, tr)))
+		case 'c':
+			b.WriteString(t.Format("2006-01-02T15:04:05.999999999-07:00"))
+		case 'd':
+			b.WriteString(t.Format("02"))
+		case 'D':
+			b.WriteString(weekdayName(t, true, tr))
+		case 'e':
+			b.WriteString(t.Location().String())
+		case 'E', 'F':
+			b.WriteString(monthName(t, false, tr))
+		case 'f':
+			b.WriteString(djangoTime(t, false, tr))
+		case 'g':
+			b.WriteString(t.Format("3"))
+		case 'G':
+			b.WriteString(strconv.Itoa(t.Hour()))
+		case 'h':
+			b.WriteString(t.Format("03"))
+		case 'H':
+			b.WriteString(t.Format("15"))
+		case 'i':
+			b.WriteString(t.Format("04"))
+		case 'j':
+			b.WriteString(strconv.Itoa(t.Day()))
+		case 'l':
+			b.WriteString(weekdayName(t, false, tr))
+		case 'L':
+			b.WriteString(fmt.Sprintf("%t", isLeapYear(t.Year())))
+		case 'm':
+			b.WriteString(t.Format("01"))
+		case 'M':
+			b.WriteString(monthName(t, true, tr))
+		case 'n':
+			b.WriteString(strconv.Itoa(int(t.Month())))
+		case 'N':
+			b.WriteString(tr.translate(djangoMonthsAP[t.Month()-1]))
+		case 'o':
+			year, _ := t.ISOWeek()
+			b.WriteString(strconv.Itoa(year))
+		case 'O':
+			b.WriteString(t.Format("-0700"))
+		case 'P':
+			b.WriteString(djangoTime(t, true, tr))
+		case 'r':
+			b.WriteString(t.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+		case 's':
+			b.WriteString(t.Format("05"))
+		case 'S':
+			b.WriteString(englishOrdinalSuffix(t.Day()))
+		case 't':
+			b.WriteString(strconv.Itoa(time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()))
+		case 'T':
+			b.WriteString(t.Format("MST"))
+		case 'u':
+			fmt.Fprintf(&b, "%06d", t.Nanosecond()/1000)
+		case 'U':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'w':
+			b.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'W':
+			_, week := t.ISOWeek()
+			b.WriteString(strconv.Itoa(week))
+		case 'y':
+			b.WriteString(t.Format("06"))
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'z':
+			b.WriteString(strconv.Itoa(t.YearDay()))
+		case 'Z':
+			_, offset := t.Zone()
+			b.WriteString(strconv.Itoa(offset))
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// djangoTime formats the time like "1:30" with the minutes omitted if they are zero.
+// With meridiem, it is like "1:30 p.m." with the special cases "midnight" and "noon".
+func djangoTime(t time.Time, meridiem bool, tr messageTranslator) string {
+	if meridiem && t.Minute() == 0 {
+		switch t.Hour() {
+		case 0:
+			return tr.translate("midnight")
+		case 12:
+			return tr.translate("noon")
+		}
+	}
+	s := t.Format("3")
+	if t.Minute() != 0 {
+		s += t.Format(":04")
+	}
+	if meridiem {
+		s += " " + djangoMeridiem(t, tr)
+	}
+	return s
+}
+
+// djangoMeridiem returns "a.m." or "p.m.".
+func djangoMeridiem(t time.Time, tr messageTranslator) string {
+	if t.Hour() < 12 {
+		return tr.translate("a.m.")
+	}
+	return tr.translate("p.m.")
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func englishOrdinalSuffix(day int) string {
+	if day >= 11 && day <= 13 {
+		return "th"
+	}
+	switch day % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	}
+	return "th"
+}
+
+var timesinceChunks = []struct {
+	seconds int64
+	unit    string
+}{
+	{60 * 60 * 24 * 365, "year"},
+	{60 * 60 * 24 * 30, "month"},
+	{60 * 60 * 24 * 7, "week"},
+	{60 * 60 * 24, "day"},
+	{60 * 60, "hour"},
+	{60, "minute"},
+}
+
+// pluralizeUnit returns the translated count with the unit, like "1 minute" or "3 minutes".
+// The messages are "%(count)s minute" and "%(count)s minutes".
+func pluralizeUnit(count int64, unit string, tr messageTranslator) string {
+	msg := tr.translatePlural("%(count)s "+unit, "%(count)s "+unit+"s", int(count))
+	return interpolateDelta(msg, "count", strconv.FormatInt(count, 10))
+}
+
+// interpolateDelta replaces the placeholder of the name in the message by the value.
+func interpolateDelta(msg string, name string, value string) string {
+	return interpolate(msg, func(n string) (string, bool) {
+		return value, n == name
+	})
+}
+
+// timesince returns the time between t and now in the two largest adjacent units, like "2 days, 3 hours".
+// It returns "0 minutes" if now is before t or they are less than a minute apart.
+func timesince(t time.Time, now time.Time, tr messageTranslator) string {
+	since := int64(now.Sub(t) / time.Second)
+	if since <= 0 {
+		return pluralizeUnit(0, "minute", tr)
+	}
+
+	for i, chunk := range timesinceChunks {
+		count := since / chunk.seconds
+		if count == 0 {
+			continue
+		}
+		s := pluralizeUnit(count, chunk.unit, tr)
+		if i+1 < len(timesinceChunks) {
+			next := timesinceChunks[i+1]
+			if count2 := (since - count*chunk.seconds) / next.seconds; count2 != 0 {
+				s += tr.translate(", ") + pluralizeUnit(count2, next.unit, tr)
+			}
+		}
+		return s
+	}
+	return pluralizeUnit(0, "minute", tr)
+}
+
+// naturaltime returns the time relative to now, like "3 minutes ago" or "2 hours from now".
+// The relative times are the messages "%(delta)s ago" and "%(delta)s from now",
+// except for "now" and the ones of a single unit like "a minute ago".
+func naturaltime(t time.Time, now time.Time, tr messageTranslator) string {
+	past := !t.After(now)
+	delta := now.Sub(t)
+	if !past {
+		delta = t.Sub(now)
+	}
+	relative := func(single string, delta string) string {
+		if past {
+			if single != "" {
+				return tr.translate(single + " ago")
+			}
+			return interpolateDelta(tr.translate("%(delta)s ago"), "delta", delta)
+		}
+		if single != "" {
+			return tr.translate(single + " from now")
+		}
+		return interpolateDelta(tr.translate("%(delta)s from now"), "delta", delta)
+	}
+
+	seconds := int64(delta / time.Second)
+	switch {
+	case seconds == 0:
+		return tr.translate("now")
+	case seconds == 1:
+		return relative("a second", "")
+	case seconds < 60:
+		return relative("", pluralizeUnit(seconds, "second", tr))
+	case seconds < 2*60:
+		return relative("a minute", "")
+	case seconds < 60*60:
+		return relative("", pluralizeUnit(seconds/60, "minute", tr))
+	case seconds < 2*60*60:
+		return relative("an hour", "")
+	case seconds < 24*60*60:
+		return relative("", pluralizeUnit(seconds/(60*60), "hour", tr))
+	}
+	if past {
+		return relative("", timesince(t, now, tr))
+	}
+	return relative("", timesince(now, t, tr))
+}
+
+func init() {
+	RegisterFilterWithArgs("date", filterDateFunc("date", defaultDateFormat))
+	RegisterFilterWithArgs("time", filterDateFunc("time", defaultTimeFormat))
+	DefaultSet.registerFilterContextKwargs("date", timezoneKwarg, localeKwarg, catalogKwarg)
+	DefaultSet.registerFilterContextKwargs("time", timezoneKwarg, localeKwarg, catalogKwarg)
+	RegisterFilterWithArgs("timesince", filterTimesince)
+	RegisterFilterWithArgs("timeuntil", filterTimeuntil)
+	RegisterFilterWithArgs("naturaltime", filterNaturaltime)
+	for _, name := range []string{"timesince", "timeuntil", "naturaltime"} {
+		DefaultSet.registerFilterContextKwargs(name, localeKwarg, catalogKwarg)
+	}
+}
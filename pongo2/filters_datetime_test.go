@@ -0,0 +1,210 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDateFilters(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	tm := time.Date(2024, 3, 1, 13, 5, 9, 0, time.UTC)
+	ctx := Context{
+		"t":    tm,
+		"tp":   &tm,
+		"nilp": (*time.Time)(nil),
+		"tz":   "Asia/Tokyo",
+	}
+
+	tests := []struct {
+		template string
+		output   string
+	}{
+		// Go layouts
+		{`{{ t|date:"2006-01-02 15:04" }}`, "2024-03-01 13:05"},
+		{`{{ t|date("Jan 2") }} {{ t|date("Monday") }}`, "Mar 1 Friday"},
+		{`{{ t|date }} {{ t|time }}`, "2024-03-01 13:05"},
+		{`{{ tp|date:"2006" }}[{{ nilp|date:"2006" }}]`, "2024[]"},
+		// Go layouts without digits or the words of the reference time are output as they are
+		{`{{ t|date:"PM" }} {{ t|date:"pm" }} {{ t|date:"Monday" }} {{ t|date:"%Y" }}`, "PM pm Friday %Y"},
+		{`{{ t|date("3:04PM", style="go") }}`, "1:05PM"},
+		// strftime
+		{`{{ t|date("%Y-%m-%d %H:%M:%S", style="strftime") }}`, "2024-03-01 13:05:09"},
+		{`{{ t|date("%a %b %e %I:%M %p, %j, %%", style="strftime") }}`, "Fri Mar  1 01:05 PM, 061, %"},
+		// Django format characters
+		{`{{ t|date("Y-m-d H:i:s", style="django") }}`, "2024-03-01 13:05:09"},
+		{`{{ t|date("N j, Y, P", style="django") }}`, "March 1, 2024, 1:05 p.m."},
+		{`{{ t|date("D, jS F y, g:i A", style="django") }}`, "Fri, 1st March 24, 1:05 PM"},
+		{`{{ t|date("l \\t\\h\\e jS", style="django") }}`, "Friday the 1st"},
+		{`{{ t|date("L t z W", style="django") }}`, "true 31 61 9"},
+		// time zones
+		{`{{ t|date("Y-m-d H:i T", style="django", tz="Asia/Tokyo") }}`, "2024-03-01 22:05 JST"},
+		{`{{ t|time("15:04", tz) }}`, "22:05"},
+		{`{{ t|date("%H:%M %z", style="strftime", tz=tz) }}`, "22:05 +0900"},
+	}
+	for _, tt := range tests {
+		out, err := set.RenderTemplateString(tt.template, ctx)
+		assert.NoError(t, err, tt.template)
+		assert.Equal(t, tt.output, out, tt.template)
+	}
+
+	t.Run("time zone of the user", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		set.TimezoneKey = "timezone"
+		tokyo, _ := time.LoadLocation("Asia/Tokyo")
+		ny, _ := time.LoadLocation("America/New_York")
+		set.Location = ny
+
+		out, err := set.RenderTemplateString(`{{ t|date("15:04") }} {{ t|date("15:04", tz="UTC") }}`, Context{"t": tm, "timezone": func() string { return "Asia/Tokyo" }})
+		assert.NoError(t, err)
+		assert.Equal(t, "22:05 13:05", out)
+
+		out, err = set.RenderTemplateString(`{{ t|date("15:04") }}`, Context{"t": tm, "timezone": tokyo})
+		assert.NoError(t, err)
+		assert.Equal(t, "22:05", out)
+
+		out, err = set.RenderTemplateString(`{{ t|date("15:04") }}`, Context{"t": tm})
+		assert.NoError(t, err)
+		assert.Equal(t, "08:05", out)
+
+		// the other filters don't get the time zone
+		assert.NoError(t, set.RegisterFilterWithArgs("kwargs", func(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+			return AsValue(len(kwargs)), nil
+		}))
+		out, err = set.RenderTemplateString(`{{ t|kwargs }}`, Context{"t": tm, "timezone": "Asia/Tokyo"})
+		assert.NoError(t, err)
+		assert.Equal(t, "0", out)
+
+		assert.NoError(t, set.ReplaceFilterWithArgs("time", func(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+			return AsValue(len(kwargs)), nil
+		}))
+		out, err = set.RenderTemplateString(`{{ t|time }}`, Context{"t": tm, "timezone": "Asia/Tokyo"})
+		assert.NoError(t, err)
+		assert.Equal(t, "0", out)
+	})
+
+	t.Run("timesince and timeuntil", func(t *testing.T) {
+		ctx := Context{
+			"t":     tm,
+			"later": tm.Add(49*time.Hour + 30*time.Minute),
+			"month": tm.AddDate(0, 0, 45),
+			"soon":  tm.Add(30 * time.Second),
+			"year":  tm.AddDate(1, 0, 1),
+		}
+		tests := []struct {
+			template string
+			output   string
+		}{
+			{`{{ t|timesince(later) }}`, "2 days, 1 hour"},
+			{`{{ t|timesince:month }}`, "1 month, 2 weeks"},
+			{`{{ t|timesince(soon) }}`, "0 minutes"},
+			{`{{ t|timesince(year) }}`, "1 year"},
+			{`{{ later|timesince(t) }}`, "0 minutes"},
+			{`{{ later|timeuntil(t) }}`, "2 days, 1 hour"},
+		}
+		for _, tt := range tests {
+			out, err := set.RenderTemplateString(tt.template, ctx)
+			assert.NoError(t, err, tt.template)
+			assert.Equal(t, tt.output, out, tt.template)
+		}
+	})
+
+	t.Run("naturaltime", func(t *testing.T) {
+		defer func(now func() time.Time) { timeNow = now }(timeNow)
+		timeNow = func() time.Time { return tm }
+
+		tests := []struct {
+			t      time.Time
+			output string
+		}{
+			{tm, "now"},
+			{tm.Add(-time.Second), "a second ago"},
+			{tm.Add(-30 * time.Second), "30 seconds ago"},
+			{tm.Add(-90 * time.Second), "a minute ago"},
+			{tm.Add(-3 * time.Minute), "3 minutes ago"},
+			{tm.Add(-time.Hour), "an hour ago"},
+			{tm.Add(-5 * time.Hour), "5 hours ago"},
+			{tm.Add(-26 * time.Hour), "1 day, 2 hours ago"},
+			{tm.Add(3 * time.Minute), "3 minutes from now"},
+			{tm.Add(72 * time.Hour), "3 days from now"},
+		}
+		for _, tt := range tests {
+			out, err := set.RenderTemplateString(`{{ t|naturaltime }}`, Context{"t": tt.t})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.output, out)
+		}
+	})
+
+	t.Run("translations", func(t *testing.T) {
+		defer func(now func() time.Time) { timeNow = now }(timeNow)
+		timeNow = func() time.Time { return tm }
+
+		catalog := NewMessageCatalog()
+		assert.NoError(t, catalog.SetPluralForms("ja", "nplurals=1; plural=0;"))
+		catalog.Add("ja", "March", "3月")
+		catalog.Add("ja", "Mar", "3月")
+		catalog.Add("ja", "Friday", "金曜日")
+		catalog.Add("ja", "Fri", "金")
+		catalog.Add("ja", "p.m.", "午後")
+		catalog.Add("ja", "%(count)s day", "%(count)s日")
+		catalog.Add("ja", "%(count)s hour", "%(count)s時間")
+		catalog.Add("ja", "%(count)s minute", "%(count)s分")
+		catalog.Add("ja", ", ", "、")
+		catalog.Add("ja", "%(delta)s ago", "%(delta)s前")
+		catalog.Add("ja", "an hour from now", "1時間後")
+		catalog.Add("ja", "now", "たった今")
+
+		set := NewSet("test", &DummyLoader{})
+		set.Catalog = catalog
+		set.LocaleKey = "locale"
+		ctx := Context{
+			"t":      tm,
+			"later":  tm.Add(49*time.Hour + 30*time.Minute),
+			"before": tm.Add(-3 * time.Minute),
+			"soon":   tm.Add(90 * time.Minute),
+			"locale": "ja",
+		}
+
+		tests := []struct {
+			template string
+			output   string
+		}{
+			{`{{ t|date("%B %b %A %a %p", style="strftime") }}`, "3月 3月 金曜日 金 PM"},
+			{`{{ t|date("F M l D P", style="django") }}`, "3月 3月 金曜日 金 1:05 午後"},
+			// Go layouts are English
+			{`{{ t|date("January Monday") }}`, "March Friday"},
+			{`{{ t|timesince(later) }}`, "2日、1時間"},
+			{`{{ later|timeuntil(t) }}`, "2日、1時間"},
+			{`{{ before|naturaltime }}`, "3分前"},
+			{`{{ soon|naturaltime }}`, "1時間後"},
+			{`{{ t|naturaltime }}`, "たった今"},
+			// the locale keyword argument
+			{`{{ t|date("F", style="django", locale="en") }}`, "March"},
+			{`{{ before|naturaltime(locale="en") }}`, "3 minutes ago"},
+		}
+		for _, tt := range tests {
+			out, err := set.RenderTemplateString(tt.template, ctx)
+			assert.NoError(t, err, tt.template)
+			assert.Equal(t, tt.output, out, tt.template)
+		}
+
+		// without the locale, the messages are not translated
+		out, err := set.RenderTemplateString(`{{ t|date("F", style="django") }} {{ before|naturaltime }}`, Context{"t": tm, "before": tm.Add(-3 * time.Minute)})
+		assert.NoError(t, err)
+		assert.Equal(t, "March 3 minutes ago", out)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := set.RenderTemplateString(`{{ "2024"|date:"2006" }}`, nil)
+		assert.ErrorContains(t, err, "filter input argument must be of type 'time.Time'")
+
+		_, err = set.RenderTemplateString(`{{ t|date("Y", tz="Nowhere/City") }}`, ctx)
+		assert.ErrorContains(t, err, "unknown time zone 'Nowhere/City'")
+
+		_, err = set.RenderTemplateString(`{{ t|date("Y", style="php") }}`, ctx)
+		assert.ErrorContains(t, err, "unknown style 'php', expected 'go', 'strftime' or 'django'")
+
+		_, err = set.RenderTemplateString(`{{ t|timesince("yesterday") }}`, ctx)
+		assert.ErrorContains(t, err, "filter argument must be of type 'time.Time'")
+	})
+}
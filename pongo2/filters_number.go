@@ -0,0 +1,149 @@
+package pongo2
+
+import (
+	"fmt"
+	"math"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// locale-aware number filters
+// Usage:
+// {{ total|number }}                       1,234.5 (en), 1.234,5 (de)
+// {{ total|number(2) }}                    1,234.50
+// {{ total|currency("EUR") }}              € 1,234.50
+// {{ total|currency("USD", display="code") }}
+// {{ ratio|percent }} {{ ratio|percent(1) }}  26% 25.6%
+// {{ size|filesizeformat }}                1.2 MB
+//
+// The locale is a BCP 47 language tag like "en-US" or a language.Tag.
+// If the locale keyword argument is not passed, the locale of TemplateSet.LocaleKey is used.
+// An unknown locale formats the numbers like English.
+
+func filterNumber(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+	if in.IsNil() {
+		return AsValue(""), nil
+	}
+	var opts []number.Option
+	if len(args) > 0 && !args[0].IsNil() {
+		opts = append(opts, number.Scale(args[0].Integer()))
+	}
+	p := filterPrinter(kwargs)
+	return AsValue(p.Sprint(number.Decimal(filterNumberInput(in), opts...))), nil
+}
+
+func filterPercent(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+	if in.IsNil() {
+		return AsValue(""), nil
+	}
+	var opts []number.Option
+	if len(args) > 0 && !args[0].IsNil() {
+		opts = append(opts, number.Scale(args[0].Integer()))
+	}
+	p := filterPrinter(kwargs)
+	return AsValue(p.Sprint(number.Percent(filterNumberInput(in), opts...))), nil
+}
+
+func filterCurrency(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+	if in.IsNil() {
+		return AsValue(""), nil
+	}
+	if len(args) == 0 || args[0].String() == "" {
+		return nil, &Error{
+			Sender:    "filter:currency",
+			OrigError: fmt.Errorf("filter currency needs a currency code like \"USD\""),
+		}
+	}
+	unit, err := currency.ParseISO(args[0].String())
+	if err != nil {
+		return nil, &Error{
+			Sender:    "filter:currency",
+			OrigError: fmt.Errorf("unknown currency code '%s'", args[0].String()),
+		}
+	}
+
+	var formatter currency.Formatter
+	switch display := kwargs.Get("display").String(); display {
+	case "", "symbol":
+		formatter = currency.Symbol
+	case "narrow":
+		formatter = currency.NarrowSymbol
+	case "code":
+		formatter = currency.ISO
+	default:
+		return nil, &Error{
+			Sender:    "filter:currency",
+			OrigError: fmt.Errorf("unknown display '%s', expected 'symbol', 'narrow' or 'code'", display),
+		}
+	}
+
+	p := filterPrinter(kwargs)
+	return AsValue(p.Sprint(formatter(unit.Amount(filterNumberInput(in))))), nil
+}
+
+// localeKwarg is the locale keyword argument of the number filters taken from the context.
+var localeKwarg = contextKwarg{name: "locale", value: func(ctx *ExecutionContext) *Value {
+	if locale := ctx.locale(); locale != "" {
+		return AsValue(locale)
+	}
+	return nil
+}}
+
+// fileSizeUnits are the units of filesizeformat by the powers of 1024.
+var fileSizeUnits = []string{"KB", "MB", "GB", "TB", "PB"}
+
+func filterFilesizeformat(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+	size := in.Float()
+	p := filterPrinter(kwargs)
+
+	abs := math.Abs(size)
+	if abs < 1024 {
+		if int64(size) == 1 {
+			return AsValue(p.Sprint(number.Decimal(int64(size))) + " byte"), nil
+		}
+		return AsValue(p.Sprint(number.Decimal(int64(size))) + " bytes"), nil
+	}
+	unit := ""
+	for _, unit = range fileSizeUnits {
+		size /= 1024
+		abs /= 1024
+		if abs < 1024 {
+			break
+		}
+	}
+	return AsValue(p.Sprint(number.Decimal(size, number.Scale(1))) + " " + unit), nil
+}
+
+// filterNumberInput returns the number of the filter input. A string is parsed as a float.
+func filterNumberInput(in *Value) any {
+	if in.IsNumber() {
+		return in.Interface()
+	}
+	return in.Float()
+}
+
+// filterPrinter returns the printer for the locale keyword argument.
+func filterPrinter(kwargs Kwargs) *message.Printer {
+	locale := kwargs.Get("locale")
+	if tag, ok := locale.Interface().(language.Tag); ok {
+		return message.NewPrinter(tag)
+	}
+	tag, err := language.Parse(locale.String())
+	if err != nil || tag == language.Und {
+		tag = language.English
+	}
+	return message.NewPrinter(tag)
+}
+
+func init() {
+	RegisterFilterWithArgs("number", filterNumber)
+	RegisterFilterWithArgs("percent", filterPercent)
+	RegisterFilterWithArgs("currency", filterCurrency)
+	RegisterFilterWithArgs("filesizeformat", filterFilesizeformat)
+	for _, name := range []string{"number", "percent", "currency", "filesizeformat"} {
+		DefaultSet.registerFilterContextKwargs(name, localeKwarg)
+	}
+}
@@ -0,0 +1,79 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"testing"
+)
+
+func TestNumberFilters(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	ctx := Context{
+		"total":  1234567.891,
+		"amount": 1234.5,
+		"count":  -42,
+		"ratio":  0.256,
+		"str":    "1234.5",
+		"nilv":   nil,
+		"neg":    -2048,
+		"german": language.German,
+	}
+
+	tests := []struct {
+		template string
+		output   string
+	}{
+		{`{{ total|number }}`, "1,234,567.891"},
+		{`{{ amount|number(2) }} {{ count|number }} {{ str|number }}`, "1,234.50 -42 1,234.5"},
+		{`{{ total|number(locale="de") }}`, "1.234.567,891"},
+		{`{{ total|number(1, locale="fr") }}`, "1\u00a0234\u00a0567,9"},
+		{`{{ total|number(locale=german) }}`, "1.234.567,891"},
+		{`{{ total|number(locale="unknown-locale") }}`, "1,234,567.891"},
+		{`[{{ nilv|number }}]`, "[]"},
+		{`{{ ratio|percent }} {{ ratio|percent(1) }} {{ 1|percent }}`, "26% 25.6% 100%"},
+		{`{{ ratio|percent(1, locale="de") }}`, "25,6\u00a0%"},
+		{`{{ amount|currency("USD") }}`, "$ 1,234.50"},
+		{`{{ amount|currency("EUR", locale="de") }}`, "€ 1.234,50"},
+		{`{{ amount|currency("JPY") }}`, "¥ 1,235"},
+		{`{{ amount|currency("USD", display="code") }}`, "USD 1,234.50"},
+		{`{{ 0|filesizeformat }} {{ 1|filesizeformat }} {{ 1023|filesizeformat }}`, "0 bytes 1 byte 1,023 bytes"},
+		{`{{ 1024|filesizeformat }} {{ 1258291|filesizeformat }} {{ 1610612736|filesizeformat }}`, "1.0 KB 1.2 MB 1.5 GB"},
+		{`{{ 1258291|filesizeformat(locale="de") }} {{ neg|filesizeformat }}`, "1,2 MB -2.0 KB"},
+	}
+	for _, tt := range tests {
+		out, err := set.RenderTemplateString(tt.template, ctx)
+		assert.NoError(t, err, tt.template)
+		assert.Equal(t, tt.output, out, tt.template)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			template string
+			err      string
+		}{
+			{`{{ amount|currency }}`, "filter currency needs a currency code like \"USD\""},
+			{`{{ amount|currency("XYZ1") }}`, "unknown currency code 'XYZ1'"},
+			{`{{ amount|currency("USD", display="full") }}`, "unknown display 'full'"},
+		}
+		for _, tt := range tests {
+			_, err := set.RenderTemplateString(tt.template, ctx)
+			assert.ErrorContains(t, err, tt.err, tt.template)
+		}
+	})
+
+	t.Run("locale of the user", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		set.LocaleKey = "locale"
+		out, err := set.RenderTemplateString(`{{ amount|number(2) }} {{ amount|number(2, locale="en") }}`, Context{"amount": 1234.5, "locale": "de-DE"})
+		assert.NoError(t, err)
+		assert.Equal(t, "1.234,50 1,234.50", out)
+
+		// the other filters don't get the locale
+		assert.NoError(t, set.RegisterFilterWithArgs("kwargs", func(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+			return AsValue(len(kwargs)), nil
+		}))
+		out, err = set.RenderTemplateString(`{{ amount|kwargs }}`, Context{"amount": 1234.5, "locale": "de-DE"})
+		assert.NoError(t, err)
+		assert.Equal(t, "0", out)
+	})
+}
@@ -0,0 +1,86 @@
+package pongo2
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestFilterWithArgs(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	assert.NoError(t, set.RegisterFilterWithArgs("money", func(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+		currency := "USD"
+		if len(args) > 0 {
+			currency = args[0].String()
+		}
+		if kwargs.Get("locale").String() == "de" {
+			return AsValue(strings.Replace(fmt.Sprintf("%.2f %s", in.Float(), currency), ".", ",", 1)), nil
+		}
+		return AsValue(fmt.Sprintf("%s %.2f", currency, in.Float())), nil
+	}))
+	assert.Error(t, set.RegisterFilterWithArgs("money", nil))
+	assert.Error(t, set.RegisterFilterWithArgs("upper", nil))
+	assert.True(t, set.FilterExists("money"))
+
+	ctx := Context{"price": 12.5, "currency": "JPY"}
+	tests := []struct {
+		template string
+		output   string
+	}{
+		{`{{ price|money }}`, "USD 12.50"},
+		{`{{ price|money:"EUR" }}`, "EUR 12.50"},
+		{`{{ price|money() }}`, "USD 12.50"},
+		{`{{ price|money(currency) }}`, "JPY 12.50"},
+		{`{{ price|money("EUR", locale="de") }}`, "12,50 EUR"},
+		{`{{ price|money(locale="de")|lower }}`, "12,50 usd"},
+		{`{% filter money("EUR", locale="de") %}3{% endfilter %}`, "3,00 EUR"},
+		// built-in filters keep working with both syntaxes
+		{`{{ "hello world"|truncatechars:8 }} {{ "hello world"|truncatechars(8) }}`, "hello... hello..."},
+		{`{% filter upper|truncatechars(4) %}hello{% endfilter %}`, "H..."},
+	}
+	for _, tt := range tests {
+		out, err := set.RenderTemplateString(tt.template, ctx)
+		assert.NoError(t, err, tt.template)
+		assert.Equal(t, tt.output, out, tt.template)
+	}
+
+	t.Run("apply", func(t *testing.T) {
+		out, err := set.ApplyFilterWithArgs("money", AsValue(1), []*Value{AsValue("EUR")}, Kwargs{"locale": AsValue("de")})
+		assert.Nil(t, err)
+		assert.Equal(t, "1,00 EUR", out.String())
+
+		out, err = set.ApplyFilter("money", AsValue(1), AsValue("EUR"))
+		assert.Nil(t, err)
+		assert.Equal(t, "EUR 1.00", out.String())
+
+		out, err = set.ApplyFilterWithArgs("upper", AsValue("a"), nil, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "A", out.String())
+
+		_, err = set.ApplyFilterWithArgs("upper", AsValue("a"), nil, Kwargs{"x": AsValue(1)})
+		assert.ErrorContains(t, err, "filter 'upper' accepts at most one argument and no keyword arguments")
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		assert.NoError(t, set.ReplaceFilterWithArgs("upper", func(in *Value, args []*Value, kwargs Kwargs) (*Value, *Error) {
+			return AsValue(strings.Repeat(strings.ToUpper(in.String()), args[0].Integer())), nil
+		}))
+		out, err := set.RenderTemplateString(`{{ "a"|upper(3) }}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "AAA", out)
+		assert.Error(t, set.ReplaceFilterWithArgs("nonexistent", nil))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := set.FromString(`{{ "a"|truncatechars(1, 2) }}`)
+		assert.ErrorContains(t, err, "Filter 'truncatechars' accepts at most one argument and no keyword arguments.")
+
+		_, err = set.FromString(`{{ price|money(locale="de", "EUR") }}`)
+		assert.ErrorContains(t, err, "Positional argument follows keyword argument.")
+
+		_, err = set.FromString(`{{ price|money("EUR" }}`)
+		assert.ErrorContains(t, err, "Missing comma or closing bracket after argument.")
+	})
+}
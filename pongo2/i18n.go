@@ -0,0 +1,144 @@
+package pongo2
+
+import (
+	"strings"
+)
+
+// The messages are translated by the Catalog of the template set in the locale of TemplateSet.LocaleKey.
+// Without the catalog or the locale, the messages are output as they are.
+//
+// A message can have placeholders like "%(name)s", which are replaced by the values of the variables.
+// "%%" is a literal "%".
+
+// lookupKey returns the value of the context key. If the value is a function, it is called.
+func (ctx *ExecutionContext) lookupKey(key string) *Value {
+	if key == "" {
+		return AsValue(nil)
+	}
+	val, err := ctx.evaluateAllowUndefined(&variableResolver{
+		parts: []*variablePart{{typ: varTypeIdent, s: key}},
+	})
+	if err != nil {
+		return AsValue(nil)
+	}
+	return val
+}
+
+// locale returns the locale of the user by TemplateSet.LocaleKey.
+func (ctx *ExecutionContext) locale() string {
+	return ctx.lookupKey(ctx.template.set.LocaleKey).String()
+}
+
+// translator returns the translator of the messages by the catalog of the template set in the locale of the user.
+func (ctx *ExecutionContext) translator() messageTranslator {
+	return messageTranslator{catalog: ctx.template.set.Catalog, locale: ctx.locale()}
+}
+
+// translate returns the translation of the message, or the message itself if it is not translated.
+func (ctx *ExecutionContext) translate(msgid string) string {
+	return ctx.translator().translate(msgid)
+}
+
+// translatePlural returns the plural form of the translation for the count n,
+// or the singular or plural message itself if it is not translated.
+func (ctx *ExecutionContext) translatePlural(msgid, msgidPlural string, n int) string {
+	return ctx.translator().translatePlural(msgid, msgidPlural, n)
+}
+
+// messageTranslator translates the messages by the catalog in the locale.
+// Without the catalog or the locale, the messages are not translated.
+type messageTranslator struct {
+	catalog Catalog
+	locale  string
+}
+
+func (t messageTranslator) translate(msgid string) string {
+	if t.catalog != nil && t.locale != "" {
+		if s, ok := t.catalog.Translate(t.locale, msgid); ok {
+			return s
+		}
+	}
+	return msgid
+}
+
+func (t messageTranslator) translatePlural(msgid, msgidPlural string, n int) string {
+	if t.catalog != nil && t.locale != "" {
+		if s, ok := t.catalog.TranslatePlural(t.locale, msgid, msgidPlural, n); ok {
+			return s
+		}
+	}
+	if n == 1 {
+		return msgid
+	}
+	return msgidPlural
+}
+
+// escapeValue returns the string of the value, escaped if autoescape is active and the value is not safe.
+func (ctx *ExecutionContext) escapeValue(v *Value) string {
+	if ctx.Autoescape && !v.safe && v.IsString() {
+		escaped, err := ctx.template.set.filters["escape"](v, nil)
+		if err == nil {
+			return escaped.String()
+		}
+	}
+	return v.String()
+}
+
+// interpolate replaces the placeholders like "%(name)s" in the message by the values.
+// The placeholders without the value are left as they are.
+func interpolate(msg string, values func(name string) (string, bool)) string {
+	if !strings.Contains(msg, "%") {
+		return msg
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c != '%' || i == len(msg)-1 {
+			b.WriteByte(c)
+			continue
+		}
+		if msg[i+1] == '%' {
+			b.WriteByte('%')
+			i++
+			continue
+		}
+		if msg[i+1] == '(' {
+			end := strings.IndexByte(msg[i:], ')')
+			// the placeholder needs the conversion character after ')'
+			if end > 0 && i+end+1 < len(msg) {
+				name := msg[i+2 : i+end]
+				if s, ok := values(name); ok {
+					b.WriteString(s)
+					i += end + 1
+					continue
+				}
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// translateFunc is the _ function to translate a message in an expression, like:
+//
+//	{{ _("Hello") }}
+//	{{ _("Hello, %(name)s", name=user.Name) }}
+//	{{ _("%(count)s item", plural="%(count)s items", count=items|length) }}
+//
+// The keyword arguments are the values of the placeholders.
+// The plural keyword argument is the plural message, and the count keyword argument selects the plural form.
+func translateFunc(ctx *ExecutionContext, msgid string, kwargs Kwargs) *Value {
+	var msg string
+	if kwargs.Has("plural") {
+		msg = ctx.translatePlural(msgid, kwargs.Get("plural").String(), kwargs.Get("count").Integer())
+	} else {
+		msg = ctx.translate(msgid)
+	}
+	return AsValue(interpolate(msg, func(name string) (string, bool) {
+		if !kwargs.Has(name) {
+			return "", false
+		}
+		return kwargs.Get(name).String(), true
+	}))
+}
@@ -0,0 +1,356 @@
+package pongo2
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Catalog is a store of the translated messages used by the trans and blocktrans tags and the _ function.
+type Catalog interface {
+	// Translate returns the translation of the message in the locale.
+	// It returns false if the message is not translated.
+	Translate(locale, msgid string) (string, bool)
+	// TranslatePlural returns the plural form of the translation for the count n.
+	// It returns false if the message is not translated.
+	TranslatePlural(locale, msgid, msgidPlural string, n int) (string, bool)
+}
+
+// MessageCatalog is an in-memory Catalog. The messages can be loaded from gettext .po files and JSON files.
+type MessageCatalog struct {
+	mu      sync.RWMutex
+	locales map[string]*catalogLocale
+}
+
+type catalogLocale struct {
+	messages map[string][]string
+	plural   pluralForms
+}
+
+// NewMessageCatalog creates a new empty MessageCatalog.
+func NewMessageCatalog() *MessageCatalog {
+	return &MessageCatalog{
+		locales: make(map[string]*catalogLocale),
+	}
+}
+
+// locale returns the messages of the locale, creating them if needed. The caller must hold the lock.
+func (c *MessageCatalog) locale(locale string) *catalogLocale {
+	l, ok := c.locales[locale]
+	if !ok {
+		l = &catalogLocale{
+			messages: make(map[string][]string),
+			plural:   defaultPluralForms,
+		}
+		c.locales[locale] = l
+	}
+	return l
+}
+
+// Add adds the translation of the message. Pass multiple translations for the plural forms.
+func (c *MessageCatalog) Add(locale, msgid string, translations ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.locale(locale).messages[msgid] = translations
+}
+
+// SetPluralForms sets the plural forms of the locale by the gettext Plural-Forms header,
+// like "nplurals=2; plural=(n != 1);". The default is the plural forms of English.
+func (c *MessageCatalog) SetPluralForms(locale, header string) error {
+	plural, err := parsePluralForms(header)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.locale(locale).plural = plural
+	return nil
+}
+
+// nplurals returns the number of the plural forms of the locale.
+func (c *MessageCatalog) nplurals(locale string) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if l, ok := c.locales[locale]; ok {
+		return l.plural.nplurals
+	}
+	return defaultPluralForms.nplurals
+}
+
+// Translate implements Catalog.
+func (c *MessageCatalog) Translate(locale, msgid string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, name := range localeCandidates(locale) {
+		if l, ok := c.locales[name]; ok {
+			if translations := l.messages[msgid]; len(translations) > 0 && translations[0] != "" {
+				return translations[0], true
+			}
+		}
+	}
+	return "", false
+}
+
+// TranslatePlural implements Catalog.
+func (c *MessageCatalog) TranslatePlural(locale, msgid, msgidPlural string, n int) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, name := range localeCandidates(locale) {
+		if l, ok := c.locales[name]; ok {
+			translations := l.messages[msgid]
+			idx := l.plural.index(n)
+			if idx < len(translations) && translations[idx] != "" {
+				return translations[idx], true
+			}
+		}
+	}
+	return "", false
+}
+
+// localeCandidates returns the locale names to look up, like "pt-BR", "pt_BR" and "pt".
+func localeCandidates(locale string) []string {
+	candidates := []string{locale}
+	if underscored := strings.ReplaceAll(locale, "-", "_"); underscored != locale {
+		candidates = append(candidates, underscored)
+	}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	return candidates
+}
+
+// LoadDir loads the message files of all locales in the directory.
+// The files are named by the locale, like "ja.po" and "pt_BR.json".
+func (c *MessageCatalog) LoadDir(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		locale := strings.TrimSuffix(name, path.Ext(name))
+		switch path.Ext(name) {
+		case ".po":
+			err = c.LoadPO(fsys, path.Join(dir, name), locale)
+		case ".json":
+			err = c.LoadJSON(fsys, path.Join(dir, name), locale)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadPO loads the messages of the locale from a gettext .po file.
+// The fuzzy messages and the messages with a context (msgctxt) are skipped, because no tag looks up a context.
+func (c *MessageCatalog) LoadPO(fsys fs.FS, name, locale string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, err := parsePO(bufio.NewScanner(f))
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	for _, entry := range entries {
+		if entry.msgid == "" {
+			// header
+			if err := c.setPluralFormsFromHeader(locale, entry.msgstr[0]); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+		if entry.fuzzy || entry.msgctxt != "" {
+			continue
+		}
+		if nplurals := c.nplurals(locale); len(entry.msgstr) > nplurals {
+			return fmt.Errorf("%s: line %d: msgstr[%d] exceeds nplurals=%d", name, entry.line, len(entry.msgstr)-1, nplurals)
+		}
+		c.Add(locale, entry.msgid, entry.msgstr...)
+	}
+	return nil
+}
+
+// LoadJSON loads the messages of the locale from a JSON file.
+// The file is an object of the message ids and the translations.
+// A translation is a string, or an array of strings for the plural forms.
+// The value of the empty key is the header like a .po file, which can have Plural-Forms.
+//
+//	{
+//	  "": "Plural-Forms: nplurals=1; plural=0;",
+//	  "Hello": "こんにちは",
+//	  "%(count)s apple": ["りんご%(count)s個"]
+//	}
+func (c *MessageCatalog) LoadJSON(fsys fs.FS, name, locale string) error {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	var messages map[string]any
+	if err := json.Unmarshal(b, &messages); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	for msgid, v := range messages {
+		switch translation := v.(type) {
+		case string:
+			if msgid == "" {
+				if err := c.setPluralFormsFromHeader(locale, translation); err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				continue
+			}
+			c.Add(locale, msgid, translation)
+		case []any:
+			translations := make([]string, 0, len(translation))
+			for _, t := range translation {
+				s, ok := t.(string)
+				if !ok {
+					return fmt.Errorf("%s: the translation of '%s' must be a string or an array of strings", name, msgid)
+				}
+				translations = append(translations, s)
+			}
+			c.Add(locale, msgid, translations...)
+		default:
+			return fmt.Errorf("%s: the translation of '%s' must be a string or an array of strings", name, msgid)
+		}
+	}
+	return nil
+}
+
+func (c *MessageCatalog) setPluralFormsFromHeader(locale, header string) error {
+	for _, line := range strings.Split(header, "\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(k), "Plural-Forms") {
+			return c.SetPluralForms(locale, strings.TrimSpace(v))
+		}
+	}
+	return nil
+}
+
+type poEntry struct {
+	msgctxt     string
+	msgid       string
+	msgidPlural string
+	msgstr      []string
+	fuzzy       bool
+	// line is the line number where the entry starts
+	line int
+}
+
+// parsePO parses the entries of a gettext .po file.
+func parsePO(scanner *bufio.Scanner) ([]*poEntry, error) {
+	var entries []*poEntry
+	var entry *poEntry
+	// target is the string that a continuation line is appended to
+	var target *string
+	fuzzy := false
+	lineNo := 0
+
+	flush := func() error {
+		if entry != nil {
+			if len(entry.msgstr) == 0 {
+				return fmt.Errorf("line %d: msgid without msgstr", entry.line)
+			}
+			entries = append(entries, entry)
+		}
+		entry = nil
+		target = nil
+		return nil
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			fuzzy = false
+			continue
+		case strings.HasPrefix(line, "#,"):
+			if strings.Contains(line, "fuzzy") {
+				fuzzy = true
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, `"`):
+			if target == nil {
+				return nil, fmt.Errorf("line %d: unexpected string", lineNo)
+			}
+			s, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			*target += s
+			continue
+		}
+
+		keyword, value, _ := strings.Cut(line, " ")
+		s, err := strconv.Unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		switch {
+		case keyword == "msgctxt" || (keyword == "msgid" && (entry == nil || len(entry.msgstr) > 0)):
+			// a new entry starts
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			entry = &poEntry{fuzzy: fuzzy, line: lineNo}
+			fuzzy = false
+		case entry == nil:
+			return nil, fmt.Errorf("line %d: unexpected %s", lineNo, keyword)
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			entry.msgctxt = s
+			target = &entry.msgctxt
+		case keyword == "msgid":
+			entry.msgid = s
+			target = &entry.msgid
+		case keyword == "msgid_plural":
+			entry.msgidPlural = s
+			target = &entry.msgidPlural
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			idx := 0
+			if keyword != "msgstr" {
+				idx, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]"))
+				if err != nil || idx < 0 || idx >= maxPluralForms {
+					return nil, fmt.Errorf("line %d: invalid %s", lineNo, keyword)
+				}
+			}
+			for len(entry.msgstr) <= idx {
+				entry.msgstr = append(entry.msgstr, "")
+			}
+			entry.msgstr[idx] = s
+			target = &entry.msgstr[idx]
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %s", lineNo, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
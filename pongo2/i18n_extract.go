@@ -0,0 +1,158 @@
+package pongo2
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ExtractedMessage is a message extracted from a template by ExtractMessages.
+type ExtractedMessage struct {
+	ID     string
+	Plural string
+	// References are the positions of the message, like "views/index.html:12".
+	References []string
+}
+
+// ExtractMessages extracts the messages of the trans and blocktrans tags and the _ function from the template source.
+// Only the messages given as string literals are extracted.
+func ExtractMessages(name string, source string) ([]*ExtractedMessage, error) {
+	tokens, lexErr := lex(name, source)
+	if lexErr != nil {
+		return nil, lexErr
+	}
+
+	var messages []*ExtractedMessage
+	add := func(id, plural string, token *Token) {
+		if id == "" {
+			return
+		}
+		messages = append(messages, &ExtractedMessage{
+			ID:         id,
+			Plural:     plural,
+			References: []string{fmt.Sprintf("%s:%d", name, token.Line)},
+		})
+	}
+
+	p := newParser(name, tokens, nil)
+	for p.Remaining() > 0 {
+		t := p.Current()
+		switch {
+		case p.Peek(TokenSymbol, "{%") != nil && p.PeekN(1, TokenIdentifier, "trans") != nil:
+			if s := p.PeekTypeN(2, TokenString); s != nil {
+				add(s.Val, "", s)
+			}
+			p.ConsumeN(2)
+		case p.Peek(TokenSymbol, "{%") != nil && p.PeekN(1, TokenIdentifier, "blocktrans") != nil:
+			p.ConsumeN(2)
+			hasCount, trimmed := false, false
+			for p.Remaining() > 0 && p.Match(TokenSymbol, "%}") == nil {
+				arg := p.Current()
+				if arg.Typ == TokenIdentifier && p.PeekN(-1, TokenSymbol, ".") == nil {
+					switch arg.Val {
+					case "count":
+						hasCount = true
+					case "trimmed":
+						trimmed = true
+					}
+				}
+				p.Consume()
+			}
+			singular, plural, err := parseBlocktransBody(p, hasCount, trimmed)
+			if err != nil {
+				return nil, err
+			}
+			add(singular, plural, t)
+		case t.Typ == TokenIdentifier && t.Val == "_" && p.PeekN(-1, TokenSymbol, ".") == nil &&
+			p.PeekN(1, TokenSymbol, "(") != nil && p.PeekTypeN(2, TokenString) != nil:
+			id := p.PeekTypeN(2, TokenString).Val
+			plural := ""
+			// look for the plural keyword argument in the call
+			depth := 0
+			for i := 1; p.GetR(i) != nil; i++ {
+				arg := p.GetR(i)
+				if arg.Typ == TokenSymbol && arg.Val == "(" {
+					depth++
+				} else if arg.Typ == TokenSymbol && arg.Val == ")" {
+					depth--
+					if depth == 0 {
+						break
+					}
+				} else if depth == 1 && arg.Typ == TokenIdentifier && arg.Val == "plural" &&
+					p.PeekN(i+1, TokenSymbol, "=") != nil && p.PeekTypeN(i+2, TokenString) != nil {
+					plural = p.PeekTypeN(i+2, TokenString).Val
+				}
+			}
+			add(id, plural, t)
+			p.Consume()
+		default:
+			p.Consume()
+		}
+	}
+
+	return messages, nil
+}
+
+// MergeMessages merges the messages of the same id, and sorts them by the id.
+func MergeMessages(messages []*ExtractedMessage) []*ExtractedMessage {
+	merged := make(map[string]*ExtractedMessage)
+	for _, m := range messages {
+		if existing, ok := merged[m.ID]; ok {
+			existing.References = append(existing.References, m.References...)
+			if existing.Plural == "" {
+				existing.Plural = m.Plural
+			}
+			continue
+		}
+		merged[m.ID] = &ExtractedMessage{
+			ID:         m.ID,
+			Plural:     m.Plural,
+			References: append([]string{}, m.References...),
+		}
+	}
+
+	result := make([]*ExtractedMessage, 0, len(merged))
+	for _, m := range merged {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// WritePOT writes the messages as a gettext .pot template file.
+func WritePOT(w io.Writer, messages []*ExtractedMessage) error {
+	var b strings.Builder
+	b.WriteString("msgid \"\"\n")
+	b.WriteString("msgstr \"\"\n")
+	b.WriteString("\"Content-Type: text/plain; charset=UTF-8\\n\"\n")
+
+	for _, m := range messages {
+		b.WriteString("\n")
+		if len(m.References) > 0 {
+			b.WriteString("#: " + strings.Join(m.References, " ") + "\n")
+		}
+		if strings.Contains(m.ID, "%(") || strings.Contains(m.Plural, "%(") {
+			b.WriteString("#, python-format\n")
+		}
+		b.WriteString("msgid " + poQuote(m.ID) + "\n")
+		if m.Plural != "" {
+			b.WriteString("msgid_plural " + poQuote(m.Plural) + "\n")
+			b.WriteString("msgstr[0] \"\"\n")
+			b.WriteString("msgstr[1] \"\"\n")
+		} else {
+			b.WriteString("msgstr \"\"\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// poQuote quotes the string for a .po file.
+func poQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,274 @@
+package pongo2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pluralForms selects the plural form of a translation by the gettext Plural-Forms header.
+type pluralForms struct {
+	nplurals int
+	plural   pluralExpr
+}
+
+// defaultPluralForms are the plural forms of English: "nplurals=2; plural=(n != 1);"
+var defaultPluralForms = pluralForms{
+	nplurals: 2,
+	plural: func(n int) int {
+		if n != 1 {
+			return 1
+		}
+		return 0
+	},
+}
+
+// index returns the index of the plural form for the count n.
+func (f pluralForms) index(n int) int {
+	idx := f.plural(n)
+	if idx < 0 || idx >= f.nplurals {
+		return 0
+	}
+	return idx
+}
+
+// maxPluralForms is the maximum number of the plural forms, which is the one of Arabic.
+const maxPluralForms = 6
+
+// parsePluralForms parses a Plural-Forms header, like "nplurals=2; plural=(n != 1);"
+func parsePluralForms(header string) (pluralForms, error) {
+	forms := pluralForms{}
+	var expr string
+	for _, field := range strings.Split(header, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(k) {
+		case "nplurals":
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil || n < 1 || n > maxPluralForms {
+				return forms, fmt.Errorf("invalid nplurals in plural forms '%s'", header)
+			}
+			forms.nplurals = n
+		case "plural":
+			expr = v
+		}
+	}
+	if forms.nplurals == 0 || expr == "" {
+		return forms, fmt.Errorf("invalid plural forms '%s'", header)
+	}
+
+	plural, err := parsePluralExpr(expr)
+	if err != nil {
+		return forms, fmt.Errorf("invalid plural forms '%s': %w", header, err)
+	}
+	forms.plural = plural
+	return forms, nil
+}
+
+// pluralExpr is a compiled plural expression of the Plural-Forms header.
+type pluralExpr func(n int) int
+
+// pluralParser parses the C expression of the plural forms:
+//
+//	expr    = or ["?" expr ":" expr]
+//	or      = and {"||" and}
+//	and     = eq {"&&" eq}
+//	eq      = rel {("==" | "!=") rel}
+//	rel     = add {("<" | "<=" | ">" | ">=") add}
+//	add     = mul {("+" | "-") mul}
+//	mul     = unary {("*" | "/" | "%") unary}
+//	unary   = "!" unary | "n" | NUMBER | "(" expr ")"
+type pluralParser struct {
+	s   string
+	pos int
+}
+
+func parsePluralExpr(s string) (pluralExpr, error) {
+	p := &pluralParser{s: s}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected '%s'", p.s[p.pos:])
+	}
+	return expr, nil
+}
+
+func (p *pluralParser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// match consumes the operator if it is next.
+func (p *pluralParser) match(op string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.s[p.pos:], op) {
+		// "<" must not match "<="
+		if len(op) == 1 && strings.Contains("<>!", op) && strings.HasPrefix(p.s[p.pos+1:], "=") {
+			return false
+		}
+		p.pos += len(op)
+		return true
+	}
+	return false
+}
+
+func (p *pluralParser) parseExpr() (pluralExpr, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.match("?") {
+		return cond, nil
+	}
+	then, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(":") {
+		return nil, fmt.Errorf("':' expected")
+	}
+	els, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) int {
+		if cond(n) != 0 {
+			return then(n)
+		}
+		return els(n)
+	}, nil
+}
+
+// pluralOperators are the binary operators by the precedence from low to high.
+var pluralOperators = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *pluralParser) parseBinary(level int) (pluralExpr, error) {
+	if level == len(pluralOperators) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range pluralOperators[level] {
+			if p.match(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = pluralBinary(op, left, right)
+	}
+}
+
+func pluralBinary(op string, left, right pluralExpr) pluralExpr {
+	boolInt := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	return func(n int) int {
+		l := left(n)
+		switch op {
+		case "||":
+			return boolInt(l != 0 || right(n) != 0)
+		case "&&":
+			return boolInt(l != 0 && right(n) != 0)
+		}
+		r := right(n)
+		switch op {
+		case "==":
+			return boolInt(l == r)
+		case "!=":
+			return boolInt(l != r)
+		case "<":
+			return boolInt(l < r)
+		case "<=":
+			return boolInt(l <= r)
+		case ">":
+			return boolInt(l > r)
+		case ">=":
+			return boolInt(l >= r)
+		case "+":
+			return l + r
+		case "-":
+			return l - r
+		case "*":
+			return l * r
+		case "/":
+			if r == 0 {
+				return 0
+			}
+			return l / r
+		case "%":
+			if r == 0 {
+				return 0
+			}
+			return l % r
+		}
+		return 0
+	}
+}
+
+func (p *pluralParser) parseUnary() (pluralExpr, error) {
+	p.skipSpaces()
+	switch {
+	case p.match("!"):
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int {
+			if operand(n) == 0 {
+				return 1
+			}
+			return 0
+		}, nil
+	case p.match("("):
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.match(")") {
+			return nil, fmt.Errorf("')' expected")
+		}
+		return expr, nil
+	case p.match("n"):
+		return func(n int) int { return n }, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos < len(p.s) {
+			return nil, fmt.Errorf("unexpected '%s'", p.s[p.pos:])
+		}
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	num, _ := strconv.Atoi(p.s[start:p.pos])
+	return func(n int) int { return num }, nil
+}
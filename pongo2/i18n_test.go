@@ -0,0 +1,288 @@
+package pongo2
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+var testLocalesFS = fstest.MapFS{
+	"locales/ja.po": &fstest.MapFile{Data: []byte(`# Japanese translations
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=1; plural=0;\n"
+
+msgid "Hello"
+msgstr "こんにちは"
+
+#, python-format
+msgid "Hello, %(name)s."
+msgstr "こんにちは、%(name)sさん。"
+
+msgid "%(count)s item"
+msgid_plural "%(count)s items"
+msgstr[0] "%(count)s個のアイテム"
+
+#, fuzzy
+msgid "Goodbye"
+msgstr "さようなら"
+
+msgid "Long message"
+msgstr ""
+"長い"
+"メッセージ"
+
+msgctxt "month"
+msgid "May"
+msgstr "5月"
+`)},
+	"locales/fr.json": &fstest.MapFile{Data: []byte(`{
+  "": "Plural-Forms: nplurals=2; plural=(n > 1);",
+  "Hello": "Bonjour",
+  "%(count)s item": ["%(count)s article", "%(count)s articles"]
+}`)},
+	"locales/README.md": &fstest.MapFile{Data: []byte(`not a message file`)},
+}
+
+func TestMessageCatalog(t *testing.T) {
+	catalog := NewMessageCatalog()
+	assert.NoError(t, catalog.LoadDir(testLocalesFS, "locales"))
+
+	tests := []struct {
+		locale string
+		msgid  string
+		output string
+		ok     bool
+	}{
+		{"ja", "Hello", "こんにちは", true},
+		{"ja-JP", "Hello", "こんにちは", true},
+		{"ja", "Long message", "長いメッセージ", true},
+		{"ja", "Goodbye", "", false},
+		// the messages with a context are skipped
+		{"ja", "May", "", false},
+		{"ja", "month\x04May", "", false},
+		{"ja", "Unknown", "", false},
+		{"fr", "Hello", "Bonjour", true},
+		{"de", "Hello", "", false},
+	}
+	for _, tt := range tests {
+		out, ok := catalog.Translate(tt.locale, tt.msgid)
+		assert.Equal(t, tt.ok, ok, tt.locale+":"+tt.msgid)
+		assert.Equal(t, tt.output, out, tt.locale+":"+tt.msgid)
+	}
+
+	out, _ := catalog.TranslatePlural("ja", "%(count)s item", "%(count)s items", 5)
+	assert.Equal(t, "%(count)s個のアイテム", out)
+	out, _ = catalog.TranslatePlural("fr", "%(count)s item", "%(count)s items", 1)
+	assert.Equal(t, "%(count)s article", out)
+	out, _ = catalog.TranslatePlural("fr", "%(count)s item", "%(count)s items", 0)
+	assert.Equal(t, "%(count)s article", out)
+	out, _ = catalog.TranslatePlural("fr", "%(count)s item", "%(count)s items", 2)
+	assert.Equal(t, "%(count)s articles", out)
+
+	t.Run("plural forms", func(t *testing.T) {
+		tests := []struct {
+			header  string
+			indexes map[int]int
+		}{
+			{"nplurals=2; plural=(n != 1);", map[int]int{0: 1, 1: 0, 2: 1}},
+			{"nplurals=1; plural=0;", map[int]int{0: 0, 1: 0, 5: 0}},
+			// Russian
+			{"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);", map[int]int{1: 0, 2: 1, 5: 2, 11: 2, 21: 0, 22: 1, 112: 2}},
+			// Arabic
+			{"nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);", map[int]int{0: 0, 1: 1, 2: 2, 3: 3, 11: 4, 100: 5}},
+			{"nplurals=2; plural=!(n == 1);", map[int]int{1: 0, 3: 1}},
+		}
+		for _, tt := range tests {
+			forms, err := parsePluralForms(tt.header)
+			assert.NoError(t, err, tt.header)
+			for n, idx := range tt.indexes {
+				assert.Equal(t, idx, forms.index(n), "%s n=%d", tt.header, n)
+			}
+		}
+
+		for _, header := range []string{"plural=(n != 1);", "nplurals=2;", "nplurals=2; plural=(n != 1", "nplurals=2; plural=n ? 1;", "nplurals=2; plural=x;"} {
+			_, err := parsePluralForms(header)
+			assert.Error(t, err, header)
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"bad.po":      &fstest.MapFile{Data: []byte("msgstr \"x\"\n")},
+			"header.po":   &fstest.MapFile{Data: []byte("msgid \"\"\n\nmsgid \"Hello\"\nmsgstr \"x\"\n")},
+			"entry.po":    &fstest.MapFile{Data: []byte("msgid \"Hello\"\nmsgstr \"x\"\n\nmsgid \"Bye\"\n")},
+			"negative.po": &fstest.MapFile{Data: []byte("msgid \"a\"\nmsgid_plural \"b\"\nmsgstr[-1] \"x\"\n")},
+			"huge.po":     &fstest.MapFile{Data: []byte("msgid \"a\"\nmsgid_plural \"b\"\nmsgstr[99999999] \"x\"\n")},
+			"nplurals.po": &fstest.MapFile{Data: []byte("msgid \"\"\nmsgstr \"Plural-Forms: nplurals=1; plural=0;\\n\"\n\nmsgid \"a\"\nmsgid_plural \"b\"\nmsgstr[0] \"x\"\nmsgstr[1] \"y\"\n")},
+			"bad.json":    &fstest.MapFile{Data: []byte(`{"Hello": 1}`)},
+			"bad2.json":   &fstest.MapFile{Data: []byte(`{"Hello": [1]}`)},
+		}
+		catalog := NewMessageCatalog()
+		assert.ErrorContains(t, catalog.LoadPO(fsys, "bad.po", "ja"), "bad.po: line 1: unexpected msgstr")
+		assert.ErrorContains(t, catalog.LoadPO(fsys, "header.po", "ja"), "header.po: line 1: msgid without msgstr")
+		assert.ErrorContains(t, catalog.LoadPO(fsys, "entry.po", "ja"), "entry.po: line 4: msgid without msgstr")
+		assert.ErrorContains(t, catalog.LoadPO(fsys, "negative.po", "ja"), "negative.po: line 3: invalid msgstr[-1]")
+		assert.ErrorContains(t, catalog.LoadPO(fsys, "huge.po", "ja"), "huge.po: line 3: invalid msgstr[99999999]")
+		assert.ErrorContains(t, catalog.LoadPO(fsys, "nplurals.po", "ko"), "nplurals.po: line 4: msgstr[1] exceeds nplurals=1")
+		assert.ErrorContains(t, catalog.LoadJSON(fsys, "bad.json", "ja"), "the translation of 'Hello' must be a string or an array of strings")
+		assert.ErrorContains(t, catalog.LoadJSON(fsys, "bad2.json", "ja"), "the translation of 'Hello' must be a string or an array of strings")
+	})
+}
+
+func TestTranslation(t *testing.T) {
+	catalog := NewMessageCatalog()
+	assert.NoError(t, catalog.LoadDir(testLocalesFS, "locales"))
+	catalog.Add("ja", "<b>Welcome</b>, %(name)s!", "<b>ようこそ</b>、%(name)sさん!")
+	catalog.Add("ja", "100%% of %(name)s", "%(name)sの100%%")
+
+	set := NewSet("test", &DummyLoader{})
+	set.Catalog = catalog
+	set.LocaleKey = "locale"
+
+	tests := []struct {
+		template string
+		output   string
+	}{
+		{`{% trans "Hello" %}`, "こんにちは"},
+		{`{% trans "Not translated" %}`, "Not translated"},
+		{`{% trans greeting %}`, "こんにちは"},
+		{`{% trans "Hello" as hello %}[{{ hello }}]`, "[こんにちは]"},
+		{`{% trans html %}`, "&lt;i&gt;"},
+		{`{% blocktrans %}Hello, {{ name }}.{% endblocktrans %}`, "こんにちは、&lt;John&gt;さん。"},
+		{`{% blocktrans with name=user %}Hello, {{ name }}.{% endblocktrans %}`, "こんにちは、Bobさん。"},
+		{`{% blocktrans %}<b>Welcome</b>, {{ name }}!{% endblocktrans %}`, "<b>ようこそ</b>、&lt;John&gt;さん!"},
+		{`{% blocktrans %}100% of {{ name }}{% endblocktrans %}`, "&lt;John&gt;の100%"},
+		{`{% blocktrans count=items|length %}{{ count }} item{% plural %}{{ count }} items{% endblocktrans %}`, "3個のアイテム"},
+		{"{% blocktrans count=1 trimmed %}\n  {{ count }} item\n{% plural %}\n  {{ count }} items\n{% endblocktrans %}", "1個のアイテム"},
+		{`{{ _("Hello") }} {{ _("Hello, %(name)s.", name=user) }}`, "こんにちは こんにちは、Bobさん。"},
+		{`{{ _("%(count)s item", plural="%(count)s items", count=2) }}`, "2個のアイテム"},
+		{`{{ _("Hello, %(name)s.", name=name) }}`, "こんにちは、&lt;John&gt;さん。"},
+	}
+	ctx := Context{
+		"locale":   "ja",
+		"greeting": "Hello",
+		"html":     "<i>",
+		"name":     "<John>",
+		"user":     "Bob",
+		"items":    []int{1, 2, 3},
+	}
+	for _, tt := range tests {
+		out, err := set.RenderTemplateString(tt.template, ctx)
+		assert.NoError(t, err, tt.template)
+		assert.Equal(t, tt.output, out, tt.template)
+	}
+
+	t.Run("fallback", func(t *testing.T) {
+		tests := []struct {
+			template string
+			output   string
+		}{
+			{`{% trans "Hello" %}`, "Bonjour"},
+			{`{% blocktrans count=1 %}{{ count }} item{% plural %}{{ count }} items{% endblocktrans %}`, "1 article"},
+			{`{% blocktrans count=2 %}{{ count }} item{% plural %}{{ count }} items{% endblocktrans %}`, "2 articles"},
+			{`{% blocktrans %}Hello, {{ name }}.{% endblocktrans %}`, "Hello, Bob."},
+		}
+		for _, tt := range tests {
+			out, err := set.RenderTemplateString(tt.template, Context{"locale": func() string { return "fr-CA" }, "name": "Bob"})
+			assert.NoError(t, err, tt.template)
+			assert.Equal(t, tt.output, out, tt.template)
+		}
+
+		// without the locale or the catalog
+		out, err := set.RenderTemplateString(`{% trans "Hello" %} {% blocktrans count=2 %}{{ count }} item{% plural %}{{ count }} items{% endblocktrans %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello 2 items", out)
+	})
+
+	t.Run("user data named _", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{{ _ }}`, Context{"_": "x"})
+		assert.NoError(t, err)
+		assert.Equal(t, "x", out)
+
+		set := NewSet("test", &DummyLoader{})
+		set.Catalog = catalog
+		set.LocaleKey = "locale"
+		set.SharedContextKeys = []string{"locale"}
+		set.ComponentSet.RegisterInlineComponent(&InlineComponent{Name: "greeting", TemplateString: `{{ _("Hello") }}`})
+		out, err = set.RenderTemplateString(`{% component "greeting" %}{% endcomponent %}`, Context{"locale": "ja"})
+		assert.NoError(t, err)
+		assert.Equal(t, "こんにちは", out)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			template string
+			err      string
+		}{
+			{`{% blocktrans %}{{ user.Name }}{% endblocktrans %}`, "Only simple variables like {{ name }} are allowed in blocktrans."},
+			{`{% blocktrans %}{% if a %}{% endif %}{% endblocktrans %}`, "Tags other than plural are not allowed in blocktrans."},
+			{`{% blocktrans %}a{% plural %}b{% endblocktrans %}`, "The plural tag needs count in blocktrans."},
+			{`{% blocktrans count=1 %}a{% endblocktrans %}`, "blocktrans with count needs the plural tag."},
+			{`{% blocktrans %}a`, "Unexpected EOF, expected tag endblocktrans."},
+			{`{% blocktrans foo %}a{% endblocktrans %}`, "Malformed blocktrans-tag arguments."},
+			{`{% trans "a" "b" %}`, "Malformed trans-tag arguments."},
+		}
+		for _, tt := range tests {
+			_, err := set.FromString(tt.template)
+			assert.ErrorContains(t, err, tt.err, tt.template)
+		}
+	})
+}
+
+func TestExtractMessages(t *testing.T) {
+	src := `<h1>{% trans "Hello" %}</h1>
+{% blocktrans with name=user.Name %}Hello, {{ name }}.{% endblocktrans %}
+{% blocktrans count n=items|length trimmed %}
+  {{ n }} item
+{% plural %}
+  {{ n }} items
+{% endblocktrans %}
+<input placeholder="{{ _("Search") }}">
+{{ _("%(count)s result", plural="%(count)s results", count=n) }}
+{{ obj._("not extracted") }}{% trans message %}
+{% trans "Hello" %}`
+
+	messages, err := ExtractMessages("index.html", src)
+	assert.NoError(t, err)
+	merged := MergeMessages(messages)
+
+	var b bytes.Buffer
+	assert.NoError(t, WritePOT(&b, merged))
+	assert.Equal(t, `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: index.html:9
+#, python-format
+msgid "%(count)s result"
+msgid_plural "%(count)s results"
+msgstr[0] ""
+msgstr[1] ""
+
+#: index.html:3
+#, python-format
+msgid "%(n)s item"
+msgid_plural "%(n)s items"
+msgstr[0] ""
+msgstr[1] ""
+
+#: index.html:1 index.html:11
+msgid "Hello"
+msgstr ""
+
+#: index.html:2
+#, python-format
+msgid "Hello, %(name)s."
+msgstr ""
+
+#: index.html:8
+msgid "Search"
+msgstr ""
+`, b.String())
+
+	_, err = ExtractMessages("bad.html", `{% blocktrans %}{{ a.b }}{% endblocktrans %}`)
+	assert.ErrorContains(t, err, "Only simple variables like {{ name }} are allowed in blocktrans.")
+}
@@ -0,0 +1,130 @@
+package pongo2
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Kwargs are the keyword arguments of a function call in a template, like: {{ route("users.show", id=user.ID) }}
+//
+// A Go function receives the keyword arguments by a parameter of the type Kwargs,
+// which must be the last parameter, or the one before the variadic parameter:
+//
+//	func(name string, kwargs pongo2.Kwargs) string
+//	func(kwargs pongo2.Kwargs, args ...*pongo2.Value) string
+//
+// A function can also receive them by an options struct (or a pointer to it) as the last parameter.
+// The struct must have the fields with the pongo2 struct tags, so other structs like time.Time are positional parameters.
+// The keyword arguments are bound to the fields in the same way as ComponentExecutionContext.Bind,
+// except that a keyword argument without the field is an error:
+//
+//	type RouteOptions struct {
+//		ID    int    `pongo2:"id"`
+//		Query string `pongo2:"query"`
+//	}
+//	func(name string, opts RouteOptions) string
+type Kwargs map[string]*Value
+
+// Get returns the value of the keyword argument, or a nil value if it is not passed.
+func (k Kwargs) Get(name string) *Value {
+	if v, ok := k[name]; ok {
+		return v
+	}
+	return AsValue(nil)
+}
+
+// Has reports whether the keyword argument is passed.
+func (k Kwargs) Has(name string) bool {
+	_, ok := k[name]
+	return ok
+}
+
+// Bind binds the keyword arguments to the struct.
+// It returns an error if a keyword argument is not bound to any field of the struct.
+func (k Kwargs) Bind(out any) error {
+	c := make(Context, len(k))
+	for name, v := range k {
+		c[name] = v
+	}
+	var metadata mapstructure.Metadata
+	if err := unmarshalContext(c, out, &metadata); err != nil {
+		return err
+	}
+	if len(metadata.Unused) > 0 {
+		sort.Strings(metadata.Unused)
+		return fmt.Errorf("unknown keyword argument '%s'", metadata.Unused[0])
+	}
+	return nil
+}
+
+var typeOfKwargs = reflect.TypeOf(Kwargs{})
+
+// kwargsParamIndex returns the index of the parameter that receives the keyword arguments, or -1.
+// An options struct receives them only if the keyword arguments are passed or the parameter is not passed positionally.
+func kwargsParamIndex(t reflect.Type, numArgs int, hasKwargs bool) int {
+	idx := t.NumIn() - 1
+	if t.IsVariadic() {
+		idx--
+	}
+	if idx < 0 {
+		return -1
+	}
+
+	pt := t.In(idx)
+	if pt == typeOfKwargs {
+		return idx
+	}
+	if pt == typeOfValuePtr || pt == typeOfExecCtxPtr {
+		return -1
+	}
+	if isKwargsStruct(pt) {
+		if hasKwargs || (!t.IsVariadic() && numArgs == t.NumIn()-1) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// isKwargsStruct reports whether the type is an options struct, or a pointer to it,
+// which has the fields with the pongo2 struct tags.
+func isKwargsStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := f.Tag.Lookup("pongo2"); ok {
+			return true
+		}
+		if f.Anonymous && isKwargsStruct(f.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// kwargsParam creates the value of the parameter that receives the keyword arguments.
+func kwargsParam(pt reflect.Type, kwargs Kwargs) (reflect.Value, error) {
+	if pt == typeOfKwargs {
+		return reflect.ValueOf(kwargs), nil
+	}
+
+	isPtr := pt.Kind() == reflect.Ptr
+	if isPtr {
+		pt = pt.Elem()
+	}
+	opts := reflect.New(pt)
+	if err := kwargs.Bind(opts.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	if isPtr {
+		return opts, nil
+	}
+	return opts.Elem(), nil
+}
@@ -0,0 +1,111 @@
+package pongo2
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testRouteOptions struct {
+	ID    int    `pongo2:"id"`
+	Query string `pongo2:"query"`
+}
+
+func TestKeywordArguments(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	ctx := Context{
+		"route": func(name string, kwargs Kwargs) string {
+			if kwargs.Has("id") {
+				return fmt.Sprintf("/%s/%d", name, kwargs.Get("id").Integer())
+			}
+			return "/" + name
+		},
+		"url": func(name string, opts testRouteOptions) string {
+			return fmt.Sprintf("/%s/%d?q=%s", name, opts.ID, opts.Query)
+		},
+		"url_ptr": func(name string, opts *testRouteOptions) string {
+			return fmt.Sprintf("/%s/%d", name, opts.ID)
+		},
+		"join": func(kwargs Kwargs, args ...*Value) string {
+			parts := make([]string, 0, len(args))
+			for _, arg := range args {
+				parts = append(parts, arg.String())
+			}
+			sep := ","
+			if kwargs.Has("sep") {
+				sep = kwargs.Get("sep").String()
+			}
+			return strings.Join(parts, sep)
+		},
+		"ctx_fn": func(ctx *ExecutionContext, kwargs Kwargs) string {
+			return kwargs.Get("name").String()
+		},
+		"plain": func(name string) string {
+			return name
+		},
+		"format": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"untagged": func(name string, opts struct{ ID int }) string {
+			return fmt.Sprintf("%s:%d", name, opts.ID)
+		},
+		"now":  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"user": map[string]any{"ID": 7},
+	}
+
+	tests := []struct {
+		template string
+		output   string
+	}{
+		{`{{ route("users.show", id=user.ID) }}`, "/users.show/7"},
+		{`{{ route("users.index") }}`, "/users.index"},
+		{`{{ url("users", id=3, query="a b") }}`, "/users/3?q=a b"},
+		{`{{ url("users") }}`, "/users/0?q="},
+		{`{{ url_ptr("users", id="5") }}`, "/users/5"},
+		{`{{ join("a", "b", sep="-") }}|{{ join("a", "b") }}|{{ join() }}`, "a-b|a,b|"},
+		{`{{ ctx_fn(name="x") }}`, "x"},
+		{`{{ route("users.show", id=1 + 1) }}`, "/users.show/2"},
+		{`{{ format("2006", now) }}`, "2024"},
+	}
+	for _, tt := range tests {
+		out, err := set.RenderTemplateString(tt.template, ctx)
+		assert.NoError(t, err, tt.template)
+		assert.Equal(t, tt.output, out, tt.template)
+	}
+
+	t.Run("bind", func(t *testing.T) {
+		var opts testRouteOptions
+		assert.NoError(t, Kwargs{"id": AsValue(3), "query": AsValue("q")}.Bind(&opts))
+		assert.Equal(t, testRouteOptions{ID: 3, Query: "q"}, opts)
+
+		err := Kwargs{"id": AsValue(3), "sort": AsValue("name"), "order": AsValue("asc")}.Bind(&opts)
+		assert.EqualError(t, err, "unknown keyword argument 'order'")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := set.RenderTemplateString(`{{ plain("a", x=1) }}`, ctx)
+		assert.ErrorContains(t, err, "does not accept keyword arguments")
+
+		// a struct without the pongo2 struct tags is a positional parameter
+		_, err = set.RenderTemplateString(`{{ format("2006") }}`, ctx)
+		assert.ErrorContains(t, err, "must be equal to the calling argument count")
+		_, err = set.RenderTemplateString(`{{ format("2006", year=2024) }}`, ctx)
+		assert.ErrorContains(t, err, "does not accept keyword arguments")
+		_, err = set.RenderTemplateString(`{{ untagged("a") }}`, ctx)
+		assert.ErrorContains(t, err, "must be equal to the calling argument count")
+
+		// an options struct rejects the unknown keyword arguments
+		_, err = set.RenderTemplateString(`{{ url("users", id=3, qurey="a") }}`, ctx)
+		assert.ErrorContains(t, err, "keyword arguments of 'url': unknown keyword argument 'qurey'")
+		_, err = set.RenderTemplateString(`{{ url_ptr("users", page=2, id=1) }}`, ctx)
+		assert.ErrorContains(t, err, "unknown keyword argument 'page'")
+
+		_, err = set.FromString(`{{ route(id=1, "users") }}`)
+		assert.ErrorContains(t, err, "Positional argument follows keyword argument.")
+
+		_, err = set.FromString(`{{ route("users", id=1, id=2) }}`)
+		assert.ErrorContains(t, err, "Keyword argument 'id' repeated.")
+	})
+}
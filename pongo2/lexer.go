@@ -33,10 +33,10 @@ var (
 		"{{-", "-}}", "{%-", "-%}",
 
 		// 2-Char symbols
-		"==", ">=", "<=", "&&", "||", "{{", "}}", "{%", "%}", "!=", "<>",
+		"==", ">=", "<=", "&&", "||", "{{", "}}", "{%", "%}", "!=", "<>", "??",
 
 		// 1-Char symbol
-		"(", ")", "+", "-", "*", "<", ">", "/", "^", ",", ".", "!", "|", ":", "=", "%", "[", "]",
+		"(", ")", "+", "-", "*", "<", ">", "/", "^", ",", ".", "!", "|", ":", "=", "%", "[", "]", "{", "}",
 	}
 
 	// Available keywords in pongo2
@@ -18,6 +18,9 @@ func (n *nodeHTML) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error
 	if n.trimRight {
 		res = strings.TrimRight(res, tokenSpaceChars)
 	}
+	if err := ctx.countOutput(len(res), n.token); err != nil {
+		return err
+	}
 	writer.WriteString(res)
 	return nil
 }
@@ -40,6 +40,29 @@ type power struct {
 	power2 IEvaluator
 }
 
+// conditionalExpression is: expr1 if cond else expr2
+type conditionalExpression struct {
+	expr1   IEvaluator
+	cond    IEvaluator
+	expr2   IEvaluator
+	opToken *Token
+}
+
+// coalesceExpression is: expr1 ?? expr2
+type coalesceExpression struct {
+	expr1   IEvaluator
+	expr2   IEvaluator
+	opToken *Token
+}
+
+func (expr *conditionalExpression) FilterApplied(name string) bool {
+	return expr.expr1.FilterApplied(name) && (expr.expr2 == nil || expr.expr2.FilterApplied(name))
+}
+
+func (expr *coalesceExpression) FilterApplied(name string) bool {
+	return expr.expr1.FilterApplied(name) && expr.expr2.FilterApplied(name)
+}
+
 func (expr *Expression) FilterApplied(name string) bool {
 	return expr.expr1.FilterApplied(name) && (expr.expr2 == nil ||
 		(expr.expr2 != nil && expr.expr2.FilterApplied(name)))
@@ -65,6 +88,14 @@ func (expr *power) FilterApplied(name string) bool {
 		(expr.power2 != nil && expr.power2.FilterApplied(name)))
 }
 
+func (expr *conditionalExpression) GetPositionToken() *Token {
+	return expr.expr1.GetPositionToken()
+}
+
+func (expr *coalesceExpression) GetPositionToken() *Token {
+	return expr.expr1.GetPositionToken()
+}
+
 func (expr *Expression) GetPositionToken() *Token {
 	return expr.expr1.GetPositionToken()
 }
@@ -85,6 +116,24 @@ func (expr *power) GetPositionToken() *Token {
 	return expr.power1.GetPositionToken()
 }
 
+func (expr *conditionalExpression) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	value, err := expr.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	writer.WriteString(value.String())
+	return nil
+}
+
+func (expr *coalesceExpression) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	value, err := expr.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	writer.WriteString(value.String())
+	return nil
+}
+
 func (expr *Expression) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
 	value, err := expr.Evaluate(ctx)
 	if err != nil {
@@ -130,6 +179,31 @@ func (expr *power) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error
 	return nil
 }
 
+func (expr *conditionalExpression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
+	cond, err := expr.cond.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cond.IsTrue() {
+		return expr.expr1.Evaluate(ctx)
+	}
+	if expr.expr2 == nil {
+		return AsValue(nil), nil
+	}
+	return expr.expr2.Evaluate(ctx)
+}
+
+func (expr *coalesceExpression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
+	v1, err := ctx.evaluateAllowUndefined(expr.expr1)
+	if err != nil {
+		return nil, err
+	}
+	if !v1.IsNil() {
+		return v1, nil
+	}
+	return expr.expr2.Evaluate(ctx)
+}
+
 func (expr *Expression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 	v1, err := expr.expr1.Evaluate(ctx)
 	if err != nil {
@@ -354,6 +428,17 @@ func (p *Parser) parseFactor() (IEvaluator, *Error) {
 		if p.Match(TokenSymbol, ")") == nil {
 			return nil, p.Error("Closing bracket expected after expression", nil)
 		}
+		if p.Peek(TokenSymbol, "|") != nil {
+			// Filters can be applied to the result of the expression: (a ?? b)|upper
+			v := &nodeFilteredVariable{
+				locationToken: expr.GetPositionToken(),
+				resolver:      expr,
+			}
+			if err := p.parseFilterChain(v); err != nil {
+				return nil, err
+			}
+			return v, nil
+		}
 		return expr, nil
 	}
 
@@ -388,7 +473,7 @@ func (p *Parser) parsePower() (IEvaluator, *Error) {
 func (p *Parser) parseTerm() (IEvaluator, *Error) {
 	returnTerm := new(term)
 
-	factor1, err := p.parsePower()
+	factor1, err := p.parseTest()
 	if err != nil {
 		return nil, err
 	}
@@ -405,7 +490,7 @@ func (p *Parser) parseTerm() (IEvaluator, *Error) {
 		op := p.Current()
 		p.Consume()
 
-		factor2, err := p.parsePower()
+		factor2, err := p.parseTest()
 		if err != nil {
 			return nil, err
 		}
@@ -503,7 +588,69 @@ func (p *Parser) parseRelationalExpression() (IEvaluator, *Error) {
 	return expr, nil
 }
 
+// ParseExpression parses an expression including the conditional expression: a if cond else b
 func (p *Parser) ParseExpression() (IEvaluator, *Error) {
+	expr1, err := p.parseCoalesceExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Peek(TokenIdentifier, "if") == nil {
+		return expr1, nil
+	}
+
+	expr := &conditionalExpression{
+		expr1:   expr1,
+		opToken: p.Current(),
+	}
+	p.Consume() // consume 'if'
+
+	cond, err := p.parseCoalesceExpression()
+	if err != nil {
+		return nil, err
+	}
+	expr.cond = cond
+
+	// The else part is optional. Without it, the expression is evaluated to nil if the condition is false.
+	if p.Match(TokenIdentifier, "else") != nil {
+		expr2, err := p.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		expr.expr2 = expr2
+	}
+
+	return expr, nil
+}
+
+// parseCoalesceExpression parses the null-coalescing operator: a ?? b
+func (p *Parser) parseCoalesceExpression() (IEvaluator, *Error) {
+	expr1, err := p.parseLogicalExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Peek(TokenSymbol, "??") == nil {
+		// Shortcut for faster evaluation
+		return expr1, nil
+	}
+
+	expr := &coalesceExpression{
+		expr1:   expr1,
+		opToken: p.Current(),
+	}
+	p.Consume() // consume '??'
+
+	expr2, err := p.parseCoalesceExpression()
+	if err != nil {
+		return nil, err
+	}
+	expr.expr2 = expr2
+
+	return expr, nil
+}
+
+func (p *Parser) parseLogicalExpression() (IEvaluator, *Error) {
 	rexpr1, err := p.parseRelationalExpression()
 	if err != nil {
 		return nil, err
@@ -516,7 +663,7 @@ func (p *Parser) ParseExpression() (IEvaluator, *Error) {
 	if p.PeekOne(TokenSymbol, "&&", "||") != nil || p.PeekOne(TokenKeyword, "and", "or") != nil {
 		op := p.Current()
 		p.Consume()
-		expr2, err := p.ParseExpression()
+		expr2, err := p.parseLogicalExpression()
 		if err != nil {
 			return nil, err
 		}
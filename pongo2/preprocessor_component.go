@@ -25,13 +25,17 @@ var (
 	verbatimPlaceholderRegex = regexp.MustCompile(`##VERBATIM_BLOCK_(\d+)##`)
 )
 
+// dynamicComponentTagName is the tag name to render a component resolved at runtime,
+// like: <x-dynamic-component :component="block.type" />
+const dynamicComponentTagName = "dynamic-component"
+
 func ComponentHTMLTagPreProcessor(config ComponentHTMLTagPreProcessorConfig) PreProcessorFunc {
 	// Regex pattern to match opening and closing tags (e.g., <x-alert>...</x-alert>)
-	openingComponentTagRegex := regexp.MustCompile(fmt.Sprintf(`<%s([a-zA-Z0-9_.-]+)([^<>]*?)\s*>`, regexp.QuoteMeta(config.TagPrefix)))
-	closingComponentTagRegex := regexp.MustCompile(fmt.Sprintf(`</%s([a-zA-Z0-9_.-]+)>`, regexp.QuoteMeta(config.TagPrefix)))
+	openingComponentTagRegex := regexp.MustCompile(fmt.Sprintf(`<%s([a-zA-Z0-9_.:-]+)([^<>]*?)\s*>`, regexp.QuoteMeta(config.TagPrefix)))
+	closingComponentTagRegex := regexp.MustCompile(fmt.Sprintf(`</%s([a-zA-Z0-9_.:-]+)>`, regexp.QuoteMeta(config.TagPrefix)))
 
 	// Regex pattern to match self-closing tags (e.g., <x-alert />)
-	selfClosingRegex := regexp.MustCompile(fmt.Sprintf(`<%s([a-zA-Z0-9_.-]+)([^<>]*?)\s*/>`, regexp.QuoteMeta(config.TagPrefix)))
+	selfClosingRegex := regexp.MustCompile(fmt.Sprintf(`<%s([a-zA-Z0-9_.:-]+)([^<>]*?)\s*/>`, regexp.QuoteMeta(config.TagPrefix)))
 
 	// Regex pattern to match opening and closing <x-slot> tags
 	openingNamedSlotTagRegex := regexp.MustCompile(fmt.Sprintf(`<%sslot([^<>]*?)\s*>`, regexp.QuoteMeta(config.TagPrefix)))
@@ -72,10 +76,23 @@ func ComponentHTMLTagPreProcessor(config ComponentHTMLTagPreProcessorConfig) Pre
 			// Generate the slot tag
 			var buffer bytes.Buffer
 			slotName := ""
+			slotArgs := ""
+			preserveWhitespace := false
+			normalAttrs := []string{}
 			for _, attr := range orderedAttrs {
-				if convertToCamelCase(attr.Name) == "name" {
+				switch attr.Name {
+				case "name":
 					slotName = attr.Value
-					break
+				case "args":
+					slotArgs = strings.TrimSpace(attr.Value)
+				case "preserve-whitespace":
+					preserveWhitespace = attr.Value != "false"
+				default:
+					if strings.HasPrefix(attr.Name, ":") {
+						normalAttrs = append(normalAttrs, fmt.Sprintf(` "%s"=%s`, strings.TrimPrefix(attr.Name, ":"), attr.Value))
+					} else {
+						normalAttrs = append(normalAttrs, fmt.Sprintf(` "%s"="%s"`, attr.Name, attr.Value))
+					}
 				}
 			}
 
@@ -83,7 +100,22 @@ func ComponentHTMLTagPreProcessor(config ComponentHTMLTagPreProcessorConfig) Pre
 				return "{% slot %}"
 			}
 
-			buffer.WriteString(fmt.Sprintf(`{%% slot "%s" %%}`, slotName))
+			buffer.WriteString(fmt.Sprintf(`{%% slot "%s"`, slotName))
+			// Add slot arguments if exists
+			if slotArgs != "" {
+				buffer.WriteString(fmt.Sprintf(` args %s`, slotArgs))
+			}
+			if preserveWhitespace {
+				buffer.WriteString(" preserveWhitespace")
+			}
+			// Add remaining attributes
+			if len(normalAttrs) > 0 {
+				buffer.WriteString(" withAttrs")
+				for _, attr := range normalAttrs {
+					buffer.WriteString(attr)
+				}
+			}
+			buffer.WriteString(" %}")
 			return buffer.String()
 		})
 
@@ -107,14 +139,23 @@ func ComponentHTMLTagPreProcessor(config ComponentHTMLTagPreProcessorConfig) Pre
 
 			// Generate the template tag
 			var buffer bytes.Buffer
-			buffer.WriteString(fmt.Sprintf(`{%% component "%s"`, componentName))
+			componentExpr := fmt.Sprintf(`"%s"`, componentName)
 
 			// Add attributes in the original order
 			slotData := ""
+			cache := ""
 			normalAttrs := []string{}
 			for _, attr := range orderedAttrs {
-				if attr.Name == "slot-data" {
+				if componentName == dynamicComponentTagName && attr.Name == "component" {
+					componentExpr = fmt.Sprintf(`"%s"`, attr.Value)
+				} else if componentName == dynamicComponentTagName && attr.Name == ":component" {
+					componentExpr = attr.Value
+				} else if attr.Name == "slot-data" {
 					slotData = fmt.Sprintf(` slotData="%s"`, attr.Value)
+				} else if attr.Name == "viewkit-cache" {
+					cache = fmt.Sprintf(` cache="%s"`, attr.Value)
+				} else if attr.Name == ":viewkit-cache" {
+					cache = fmt.Sprintf(` cache=%s`, attr.Value)
 				} else if strings.HasPrefix(attr.Name, ":") {
 					normalAttrs = append(normalAttrs, fmt.Sprintf(` "%s"=%s`, strings.TrimPrefix(attr.Name, ":"), attr.Value))
 				} else {
@@ -122,11 +163,18 @@ func ComponentHTMLTagPreProcessor(config ComponentHTMLTagPreProcessorConfig) Pre
 				}
 			}
 
+			buffer.WriteString(fmt.Sprintf(`{%% component %s`, componentExpr))
+
 			// Add slotData if exists
 			if slotData != "" {
 				buffer.WriteString(slotData)
 			}
 
+			// Add cache if exists
+			if cache != "" {
+				buffer.WriteString(cache)
+			}
+
 			// Add remaining attributes
 			if len(normalAttrs) > 0 {
 				buffer.WriteString(" withAttrs")
@@ -154,14 +202,23 @@ func ComponentHTMLTagPreProcessor(config ComponentHTMLTagPreProcessorConfig) Pre
 
 			// Generate the template tag
 			var buffer bytes.Buffer
-			buffer.WriteString(fmt.Sprintf(`{%% component "%s"`, componentName))
+			componentExpr := fmt.Sprintf(`"%s"`, componentName)
 
 			// Add attributes in the original order
 			slotData := ""
+			cache := ""
 			normalAttrs := []string{}
 			for _, attr := range orderedAttrs {
-				if attr.Name == "slot-data" {
+				if componentName == dynamicComponentTagName && attr.Name == "component" {
+					componentExpr = fmt.Sprintf(`"%s"`, attr.Value)
+				} else if componentName == dynamicComponentTagName && attr.Name == ":component" {
+					componentExpr = attr.Value
+				} else if attr.Name == "slot-data" {
 					slotData = fmt.Sprintf(` slotData="%s"`, attr.Value)
+				} else if attr.Name == "viewkit-cache" {
+					cache = fmt.Sprintf(` cache="%s"`, attr.Value)
+				} else if attr.Name == ":viewkit-cache" {
+					cache = fmt.Sprintf(` cache=%s`, attr.Value)
 				} else if strings.HasPrefix(attr.Name, ":") {
 					normalAttrs = append(normalAttrs, fmt.Sprintf(` "%s"=%s`, strings.TrimPrefix(attr.Name, ":"), attr.Value))
 				} else {
@@ -169,11 +226,18 @@ func ComponentHTMLTagPreProcessor(config ComponentHTMLTagPreProcessorConfig) Pre
 				}
 			}
 
+			buffer.WriteString(fmt.Sprintf(`{%% component %s`, componentExpr))
+
 			// Add slotData if exists
 			if slotData != "" {
 				buffer.WriteString(slotData)
 			}
 
+			// Add cache if exists
+			if cache != "" {
+				buffer.WriteString(cache)
+			}
+
 			// Add remaining attributes
 			if len(normalAttrs) > 0 {
 				buffer.WriteString(" withAttrs")
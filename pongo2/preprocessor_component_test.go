@@ -113,6 +113,36 @@ func TestComponentHTMLTagPreProcessor(t *testing.T) {
 			input:  `<x-alert hoge="aa"><x-slot name="aaa">hoge</x-slot></x-alert>`,
 			output: `{% component "alert" withAttrs "hoge"="aa" %}{% slot "aaa" %}hoge{% endslot %}{% endcomponent %}`,
 		},
+		{
+			config: defaultConfig,
+			input:  `<x-table><x-slot name="row" args="item, index">{{ item }}</x-slot></x-table>`,
+			output: `{% component "table" %}{% slot "row" args item, index %}{{ item }}{% endslot %}{% endcomponent %}`,
+		},
+		{
+			config: defaultConfig,
+			input:  `<x-card><x-slot name="footer" class="mt-4" :id="footerId" preserve-whitespace="true"> foo </x-slot></x-card>`,
+			output: `{% component "card" %}{% slot "footer" preserveWhitespace withAttrs "class"="mt-4" "id"=footerId %} foo {% endslot %}{% endcomponent %}`,
+		},
+		{
+			config: defaultConfig,
+			input:  `<x-dynamic-component :component="block.type" :title="block.title" class="mt-4" />`,
+			output: `{% component block.type withAttrs "title"=block.title "class"="mt-4" %}{% endcomponent %}`,
+		},
+		{
+			config: defaultConfig,
+			input:  `<x-dynamic-component component="alert">hello</x-dynamic-component>`,
+			output: `{% component "alert" %}hello{% endcomponent %}`,
+		},
+		{
+			config: defaultConfig,
+			input:  `<x-ui::button type="submit">Save</x-ui::button><x-ui::forms.input />`,
+			output: `{% component "ui::button" withAttrs "type"="submit" %}Save{% endcomponent %}{% component "ui::forms.input" %}{% endcomponent %}`,
+		},
+		{
+			config: defaultConfig,
+			input:  `<x-nav viewkit-cache="300" active="home" /><x-nav :viewkit-cache="ttl">a</x-nav><x-nav cache="yes" />`,
+			output: `{% component "nav" cache="300" withAttrs "active"="home" %}{% endcomponent %}{% component "nav" cache=ttl %}a{% endcomponent %}{% component "nav" withAttrs "cache"="yes" %}{% endcomponent %}`,
+		},
 		{
 			config: defaultConfig,
 			input: `<x-alert hoge="aa">
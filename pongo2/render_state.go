@@ -0,0 +1,316 @@
+package pongo2
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"strings"
+	"time"
+)
+
+// renderState is the state shared across a whole render,
+// including the nested executions of included templates and components.
+type renderState struct {
+	// stacks holds the content pushed by the push tags
+	stacks map[string][]string
+	// pushed records the pushonce tags that are already pushed
+	pushed map[string]bool
+	// declared holds the names of the stacks declared by the stack tags
+	declared map[string]bool
+	// nonce makes the stack placeholders unique in the render
+	nonce string
+	// recordings record the pushes and the stacks for the cached output, see record
+	recordings []*renderRecording
+
+	// sandbox is the sandbox policy of the template set, or nil
+	sandbox *SandboxPolicy
+	// ctx cancels the render. It is nil if the render can't be canceled.
+	ctx context.Context
+	// timeout is the render timeout of the template set
+	timeout time.Duration
+	// loopIterations, outputSize and componentDepth are counted for the limits of the sandbox policy
+	loopIterations int
+	outputSize     int
+	componentDepth int
+}
+
+func newRenderState() *renderState {
+	return &renderState{
+		stacks:   make(map[string][]string),
+		pushed:   make(map[string]bool),
+		declared: make(map[string]bool),
+	}
+}
+
+// begin starts the render with the context of the request, the render timeout and the sandbox policy of the template set.
+// The parent context can be nil. The returned function must be called at the end of the render.
+func (s *renderState) begin(set *TemplateSet, parent context.Context) func() {
+	s.sandbox = set.Sandbox
+	s.timeout = set.RenderTimeout
+	sandboxTimeout := time.Duration(0)
+	if set.Sandbox != nil {
+		sandboxTimeout = set.Sandbox.Timeout
+	}
+	if parent == nil && s.timeout <= 0 && sandboxTimeout <= 0 {
+		return func() {}
+	}
+
+	if parent == nil {
+		parent = context.Background()
+	}
+	s.ctx = parent
+	var cancels []context.CancelFunc
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		s.ctx, cancel = context.WithTimeoutCause(s.ctx, s.timeout, errRenderTimeout)
+		cancels = append(cancels, cancel)
+	}
+	if sandboxTimeout > 0 {
+		var cancel context.CancelFunc
+		s.ctx, cancel = context.WithTimeoutCause(s.ctx, sandboxTimeout, errSandboxTimeout)
+		cancels = append(cancels, cancel)
+	}
+	return func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
+
+// requestContext returns the context of the request of the echo context, or nil.
+func requestContext(c echo.Context) context.Context {
+	if c == nil || c.Request() == nil {
+		return nil
+	}
+	return c.Request().Context()
+}
+
+// errRenderTimeout is the cause of the context canceled by TemplateSet.RenderTimeout.
+var errRenderTimeout = errors.New("render timeout")
+
+// RenderCanceledError is the error of a render that is aborted because the context of the request is canceled,
+// like when the client disconnects, or the render exceeds TemplateSet.RenderTimeout.
+// It is wrapped by *Error, so use errors.As to inspect it.
+type RenderCanceledError struct {
+	// Timeout is the render timeout if the render exceeded it, or zero if the context of the request is canceled.
+	Timeout time.Duration
+	// Err is the error of the context, context.Canceled or context.DeadlineExceeded.
+	Err error
+}
+
+func (e *RenderCanceledError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("the render exceeded the timeout (%v)", e.Timeout)
+	}
+	return "the render was canceled: " + e.Err.Error()
+}
+
+// Unwrap returns the error of the context, so that errors.Is(err, context.Canceled) works.
+func (e *RenderCanceledError) Unwrap() error {
+	return e.Err
+}
+
+// checkCanceled returns an error if the render is canceled or exceeds the timeout.
+func (ctx *ExecutionContext) checkCanceled(token *Token) *Error {
+	if ctx.render == nil || ctx.render.ctx == nil {
+		return nil
+	}
+	err := ctx.render.ctx.Err()
+	if err == nil {
+		return nil
+	}
+	switch context.Cause(ctx.render.ctx) {
+	case errSandboxTimeout:
+		return ctx.OrigError(&SandboxError{
+			Violation: SandboxTimeout,
+			Message:   fmt.Sprintf("the render exceeded the timeout (%v)", ctx.render.sandbox.Timeout),
+			cause:     err,
+		}, token)
+	case errRenderTimeout:
+		return ctx.OrigError(&RenderCanceledError{Timeout: ctx.render.timeout, Err: err}, token)
+	}
+	return ctx.OrigError(&RenderCanceledError{Err: err}, token)
+}
+
+func (s *renderState) push(name string, content string) {
+	s.stacks[name] = append(s.stacks[name], content)
+	for _, r := range s.recordings {
+		r.Pushes = append(r.Pushes, &recordedPush{Stack: name, Content: content})
+	}
+}
+
+// isPushed reports whether the content with the key is already pushed to the stack by the pushonce tag.
+func (s *renderState) isPushed(name string, key string) bool {
+	return s.pushed[name+"\x00"+key]
+}
+
+// pushOnce pushes the content only if the key is not pushed yet.
+// The content is recorded even if the key is already pushed, because the recorded output can be replayed in another render.
+func (s *renderState) pushOnce(name string, key string, content string) {
+	for _, r := range s.recordings {
+		r.Pushes = append(r.Pushes, &recordedPush{Stack: name, Key: key, Once: true, Content: content})
+	}
+	if s.isPushed(name, key) {
+		return
+	}
+	s.pushed[name+"\x00"+key] = true
+	s.stacks[name] = append(s.stacks[name], content)
+}
+
+// recording reports whether a part of the render is being recorded.
+func (s *renderState) recording() bool {
+	return len(s.recordings) > 0
+}
+
+// stackPlaceholder declares the stack and returns the placeholder that is replaced with the stack content after the render.
+func (s *renderState) stackPlaceholder(name string) string {
+	if s.nonce == "" {
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		s.nonce = hex.EncodeToString(b)
+	}
+	s.declared[name] = true
+	for _, r := range s.recordings {
+		r.declare(name)
+	}
+	return fmt.Sprintf("<!--pongo2-stack:%s:%s-->", s.nonce, name)
+}
+
+// renderRecording is the output of a part of a render with the pushes and the stacks in it.
+// It is stored by the cache tag and the cached components, and replayed on a cache hit,
+// so the pushed content reaches the stacks and the stacks in the output are resolved in the render of the hit.
+type renderRecording struct {
+	Output string `json:"output"`
+	// Nonce is the nonce of the stack placeholders in the output
+	Nonce  string          `json:"nonce,omitempty"`
+	Stacks []string        `json:"stacks,omitempty"`
+	Pushes []*recordedPush `json:"pushes,omitempty"`
+}
+
+type recordedPush struct {
+	Stack   string `json:"stack"`
+	Key     string `json:"key,omitempty"`
+	Once    bool   `json:"once,omitempty"`
+	Content string `json:"content"`
+}
+
+func (r *renderRecording) declare(name string) {
+	for _, stack := range r.Stacks {
+		if stack == name {
+			return
+		}
+	}
+	r.Stacks = append(r.Stacks, name)
+}
+
+// record executes fn recording the pushes and the stacks, and returns the recording of the output written by fn.
+func (s *renderState) record(fn func(writer TemplateWriter) *Error) (*renderRecording, *Error) {
+	r := &renderRecording{}
+	s.recordings = append(s.recordings, r)
+	var b bytes.Buffer
+	err := fn(&b)
+	s.recordings = s.recordings[:len(s.recordings)-1]
+	if err != nil {
+		return nil, err
+	}
+	r.Output = b.String()
+	if len(r.Stacks) > 0 {
+		r.Nonce = s.nonce
+	}
+	return r, nil
+}
+
+// replay pushes the recorded content to the stacks, and writes the recorded output with the stack placeholders of this render.
+func (s *renderState) replay(r *renderRecording, writer TemplateWriter) {
+	for _, p := range r.Pushes {
+		if p.Once {
+			s.pushOnce(p.Stack, p.Key, p.Content)
+		} else {
+			s.push(p.Stack, p.Content)
+		}
+	}
+	out := r.Output
+	for _, name := range r.Stacks {
+		recorded := fmt.Sprintf("<!--pongo2-stack:%s:%s-->", r.Nonce, name)
+		out = strings.ReplaceAll(out, recorded, s.stackPlaceholder(name))
+	}
+	writer.WriteString(out)
+}
+
+// encodeRecording encodes the recording to store it in a Cache.
+func encodeRecording(r *renderRecording) (string, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// decodeRecording decodes the recording stored in a Cache. It returns false for a malformed entry.
+func decodeRecording(entry string) (*renderRecording, bool) {
+	r := &renderRecording{}
+	if err := json.Unmarshal([]byte(entry), r); err != nil {
+		return nil, false
+	}
+	return r, true
+}
+
+// resolve replaces the stack placeholders in the rendered output with the pushed content.
+func (s *renderState) resolve(out []byte) []byte {
+	if len(s.declared) == 0 {
+		return out
+	}
+	for name := range s.declared {
+		out = bytes.ReplaceAll(out, []byte(s.stackPlaceholder(name)), []byte(strings.Join(s.stacks[name], "")))
+	}
+	return out
+}
+
+// stackWriter writes the output of a render to the writer as it is until a stack tag is executed.
+// The output after it is buffered to replace the placeholders of the stacks with the pushed content
+// at the end of the render, so a template without stack tags is not buffered.
+type stackWriter struct {
+	writer TemplateWriter
+	render *renderState
+	buffer bytes.Buffer
+	// written is the size of the output written to the writer
+	written int
+}
+
+func newStackWriter(writer TemplateWriter, render *renderState) *stackWriter {
+	return &stackWriter{writer: writer, render: render}
+}
+
+func (w *stackWriter) Write(p []byte) (int, error) {
+	if len(w.render.declared) > 0 {
+		return w.buffer.Write(p)
+	}
+	w.written += len(p)
+	return w.writer.Write(p)
+}
+
+func (w *stackWriter) WriteString(s string) (int, error) {
+	if len(w.render.declared) > 0 {
+		return w.buffer.WriteString(s)
+	}
+	w.written += len(s)
+	return w.writer.WriteString(s)
+}
+
+// flush writes the buffered output with the pushed content of the stacks.
+func (w *stackWriter) flush() error {
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	out := w.render.resolve(w.buffer.Bytes())
+	if err := w.render.checkOutputSize(w.written + len(out)); err != nil {
+		return err
+	}
+	_, err := w.writer.Write(out)
+	return err
+}
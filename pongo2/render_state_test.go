@@ -0,0 +1,81 @@
+package pongo2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRenderCancellation(t *testing.T) {
+	set := NewSet("test", NewFSLoader(fstest.MapFS{
+		"partial.html": &fstest.MapFile{Data: []byte(`partial`)},
+	}))
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "box",
+		TemplateString: `[{{ slot }}]`,
+	})
+
+	newEchoContext := func(ctx context.Context) echo.Context {
+		req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+		return echo.New().NewContext(req, httptest.NewRecorder())
+	}
+
+	t.Run("not canceled", func(t *testing.T) {
+		tpl, err := set.FromString(`{% for i in "123" %}{{ i }}{% endfor %}{% include "partial.html" %}{% component "box" %}x{% endcomponent %}`)
+		assert.NoError(t, err)
+		var b bytes.Buffer
+		assert.NoError(t, tpl.ExecuteWriterWithEchoContext(nil, &b, newEchoContext(context.Background())))
+		assert.Equal(t, "123partial[x]", b.String())
+	})
+
+	t.Run("canceled by the request", func(t *testing.T) {
+		tests := []string{
+			`{% for i in "123" %}{{ i }}{% if i == "2" %}{{ cancel() }}{% endif %}{% endfor %}`,
+			`{{ cancel() }}{% include "partial.html" %}`,
+			`{{ cancel() }}{% component "box" %}x{% endcomponent %}`,
+			`{% macro m() %}x{% endmacro %}{{ cancel() }}{{ m() }}`,
+		}
+		for _, template := range tests {
+			ctx, cancel := context.WithCancel(context.Background())
+			tpl, err := set.FromString(template)
+			assert.NoError(t, err)
+
+			var b bytes.Buffer
+			err = tpl.ExecuteWriterWithEchoContext(Context{"cancel": func() string { cancel(); return "" }}, &b, newEchoContext(ctx))
+			var canceledErr *RenderCanceledError
+			if assert.True(t, errors.As(err, &canceledErr), template) {
+				assert.Equal(t, time.Duration(0), canceledErr.Timeout)
+			}
+			assert.True(t, errors.Is(err, context.Canceled), template)
+			assert.ErrorContains(t, err, "the render was canceled: context canceled", template)
+			// Nothing is written on error
+			assert.Equal(t, "", b.String(), template)
+		}
+	})
+
+	t.Run("render timeout", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		set.RenderTimeout = 20 * time.Millisecond
+		sleep := func() string {
+			time.Sleep(5 * time.Millisecond)
+			return ""
+		}
+		_, err := set.RenderTemplateString(`{% for i in "1234567890" %}{{ sleep() }}{% endfor %}`, Context{"sleep": sleep})
+		var canceledErr *RenderCanceledError
+		if assert.True(t, errors.As(err, &canceledErr)) {
+			assert.Equal(t, 20*time.Millisecond, canceledErr.Timeout)
+		}
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.ErrorContains(t, err, "the render exceeded the timeout (20ms)")
+
+		out, err := set.RenderTemplateString(`{% for i in "123" %}{{ i }}{% endfor %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "123", out)
+	})
+}
@@ -0,0 +1,342 @@
+package pongo2
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SandboxPolicy restricts what the templates of a TemplateSet can do at execution.
+// It is for the templates written by untrusted users, like the email templates edited by customers.
+// Use it with BanTag and BanFilter to disallow the tags and filters that the templates must not use.
+//
+// A zero limit means no limit. Use NewSandboxPolicy to start with the default limits.
+type SandboxPolicy struct {
+	// AllowedMethods are the methods that the templates can call on Go values, like "time.Time.Format".
+	// The name is the type name without the pointer and the method name. "time.Time.*" allows all methods of the type.
+	// The functions in the context, like the shared context providers and the macros, are not restricted.
+	AllowedMethods []string
+
+	// BlockedPackages are the packages whose types the templates can't access, like "os" and "net/http".
+	// The channels and the unsafe pointers are always blocked.
+	BlockedPackages []string
+
+	// MaxLoopIterations is the maximum number of the iterations of all for loops in a render.
+	MaxLoopIterations int
+
+	// MaxOutputSize is the maximum size of the output in bytes.
+	// The text and the variables are counted when they are output, so the output of a macro or a slot
+	// is counted when it is rendered and when it is inserted.
+	MaxOutputSize int
+
+	// MaxMacroDepth is the maximum depth of the recursive macro calls.
+	MaxMacroDepth int
+
+	// MaxComponentDepth is the maximum depth of the nested components.
+	MaxComponentDepth int
+
+	// Timeout is the maximum duration of a render.
+	Timeout time.Duration
+}
+
+// DefaultSandboxBlockedPackages are the packages blocked by the policy of NewSandboxPolicy.
+var DefaultSandboxBlockedPackages = []string{
+	"database/sql",
+	"io/fs",
+	"net",
+	"net/http",
+	"os",
+	"os/exec",
+	"plugin",
+	"reflect",
+	"runtime",
+	"syscall",
+	"unsafe",
+	"github.com/labstack/echo/v4",
+}
+
+// NewSandboxPolicy creates a new SandboxPolicy with the default limits.
+// No methods are allowed.
+func NewSandboxPolicy() *SandboxPolicy {
+	return &SandboxPolicy{
+		BlockedPackages:   append([]string{}, DefaultSandboxBlockedPackages...),
+		MaxLoopIterations: 10000,
+		MaxOutputSize:     1 << 20, // 1 MiB
+		MaxMacroDepth:     50,
+		MaxComponentDepth: 20,
+		Timeout:           time.Second,
+	}
+}
+
+// SandboxViolation is the kind of a violation of a SandboxPolicy.
+type SandboxViolation string
+
+const (
+	SandboxMethodNotAllowed SandboxViolation = "method not allowed"
+	SandboxTypeBlocked      SandboxViolation = "type blocked"
+	SandboxUnexportedField  SandboxViolation = "unexported field"
+	SandboxLoopIterations   SandboxViolation = "loop iterations"
+	SandboxOutputSize       SandboxViolation = "output size"
+	SandboxMacroDepth       SandboxViolation = "macro depth"
+	SandboxComponentDepth   SandboxViolation = "component depth"
+	SandboxTimeout          SandboxViolation = "timeout"
+)
+
+// SandboxError is the error of a template that violates the SandboxPolicy.
+// It is wrapped by *Error, so use errors.As to inspect it:
+//
+//	var sandboxErr *pongo2.SandboxError
+//	if errors.As(err, &sandboxErr) && sandboxErr.Violation == pongo2.SandboxTimeout {
+//		...
+//	}
+type SandboxError struct {
+	Violation SandboxViolation
+	Message   string
+	// cause is the error of the context for a timeout
+	cause error
+}
+
+func (e *SandboxError) Error() string {
+	return "sandbox: " + e.Message
+}
+
+// Unwrap returns context.DeadlineExceeded for a timeout.
+func (e *SandboxError) Unwrap() error {
+	return e.cause
+}
+
+// allowsMethod reports whether the method of the type is in the allowlist.
+func (p *SandboxPolicy) allowsMethod(t reflect.Type, method string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.PkgPath() == pongo2PkgPath {
+		// the methods of pongo2, like the ones of the component attributes, are always allowed
+		return true
+	}
+	return p.allowlistsMethod(t, method)
+}
+
+// allowlistsMethod reports whether the method of the type is in AllowedMethods.
+func (p *SandboxPolicy) allowlistsMethod(t reflect.Type, method string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name := t.String()
+	for _, allowed := range p.AllowedMethods {
+		if allowed == name+"."+method || allowed == name+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// errSandboxTimeout is the cause of the context canceled by the timeout of the sandbox policy.
+var errSandboxTimeout = errors.New("sandbox timeout")
+
+// pongo2PkgPath is the package path of pongo2, whose unexported types like the forloop are accessible.
+var pongo2PkgPath = reflect.TypeOf(Value{}).PkgPath()
+
+// blockedType returns the type that the templates can't access in the type, or nil.
+// The element types of the pointers, slices, arrays and maps are checked as well.
+func (p *SandboxPolicy) blockedType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Chan, reflect.UnsafePointer:
+			return t
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			t = t.Elem()
+			continue
+		case reflect.Map:
+			if blocked := p.blockedType(t.Key()); blocked != nil {
+				return blocked
+			}
+			t = t.Elem()
+			continue
+		}
+		break
+	}
+
+	pkgPath := t.PkgPath()
+	if pkgPath == "" || pkgPath == pongo2PkgPath {
+		return nil
+	}
+	if !isExportedName(t.Name()) {
+		return t
+	}
+	for _, blocked := range p.BlockedPackages {
+		if pkgPath == blocked {
+			return t
+		}
+	}
+	return nil
+}
+
+// maxSandboxValueDepth is the maximum depth of the values that blockedValue walks.
+const maxSandboxValueDepth = 32
+
+// blockedValue returns the type that the templates can't access in the value, or nil.
+// Unlike blockedType, it checks the dynamic types of the values in the interfaces, like the elements of map[string]any,
+// because the templates output the whole maps and slices with %v.
+func (p *SandboxPolicy) blockedValue(v reflect.Value) reflect.Type {
+	return p.walkBlockedValue(v, make(map[uintptr]bool), 0)
+}
+
+func (p *SandboxPolicy) walkBlockedValue(v reflect.Value, visited map[uintptr]bool, depth int) reflect.Type {
+	if !v.IsValid() || depth > maxSandboxValueDepth {
+		return nil
+	}
+	if blocked := p.blockedType(v.Type()); blocked != nil {
+		return blocked
+	}
+	if v.Type() == typeOfValuePtr {
+		if v.IsNil() {
+			return nil
+		}
+		return p.walkBlockedValue(v.Interface().(*Value).val, visited, depth+1)
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return p.walkBlockedValue(v.Elem(), visited, depth+1)
+	case reflect.Ptr:
+		if v.IsNil() || visited[v.Pointer()] {
+			return nil
+		}
+		visited[v.Pointer()] = true
+		return p.walkBlockedValue(v.Elem(), visited, depth+1)
+	case reflect.Slice, reflect.Array:
+		if !mayHoldDynamicType(v.Type().Elem()) {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if blocked := p.walkBlockedValue(v.Index(i), visited, depth+1); blocked != nil {
+				return blocked
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() || visited[v.Pointer()] {
+			return nil
+		}
+		visited[v.Pointer()] = true
+		if !mayHoldDynamicType(v.Type().Key()) && !mayHoldDynamicType(v.Type().Elem()) {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if blocked := p.walkBlockedValue(iter.Key(), visited, depth+1); blocked != nil {
+				return blocked
+			}
+			if blocked := p.walkBlockedValue(iter.Value(), visited, depth+1); blocked != nil {
+				return blocked
+			}
+		}
+	case reflect.Struct:
+		// the unexported fields are not accessible from the templates
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if blocked := p.walkBlockedValue(v.Field(i), visited, depth+1); blocked != nil {
+				return blocked
+			}
+		}
+	}
+	return nil
+}
+
+// mayHoldDynamicType reports whether the values of the type can hold a value whose type is not checked by blockedType.
+func mayHoldDynamicType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return true
+	}
+	return false
+}
+
+func isExportedName(name string) bool {
+	return name != "" && strings.ToUpper(name[:1]) == name[:1]
+}
+
+// sandbox returns the sandbox policy of the render, or nil.
+func (ctx *ExecutionContext) sandbox() *SandboxPolicy {
+	if ctx.render == nil {
+		return nil
+	}
+	return ctx.render.sandbox
+}
+
+// countLoopIteration counts an iteration of a for loop, and returns an error if it exceeds the limit.
+func (ctx *ExecutionContext) countLoopIteration(token *Token) *Error {
+	sandbox := ctx.sandbox()
+	if sandbox == nil {
+		return nil
+	}
+	ctx.render.loopIterations++
+	if sandbox.MaxLoopIterations > 0 && ctx.render.loopIterations > sandbox.MaxLoopIterations {
+		return ctx.OrigError(&SandboxError{
+			Violation: SandboxLoopIterations,
+			Message:   fmt.Sprintf("the loops exceeded the maximum number of iterations (max is %d)", sandbox.MaxLoopIterations),
+		}, token)
+	}
+	return nil
+}
+
+// countOutput counts the size of the output, and returns an error if it exceeds the limit.
+func (ctx *ExecutionContext) countOutput(size int, token *Token) *Error {
+	sandbox := ctx.sandbox()
+	if sandbox == nil || sandbox.MaxOutputSize <= 0 {
+		return nil
+	}
+	ctx.render.outputSize += size
+	if ctx.render.outputSize > sandbox.MaxOutputSize {
+		return ctx.OrigError(outputSizeError(sandbox), token)
+	}
+	return nil
+}
+
+func outputSizeError(sandbox *SandboxPolicy) *SandboxError {
+	return &SandboxError{
+		Violation: SandboxOutputSize,
+		Message:   fmt.Sprintf("the output exceeded the maximum size (max is %d bytes)", sandbox.MaxOutputSize),
+	}
+}
+
+// checkOutputSize returns an error if the size of the final output exceeds the limit.
+func (s *renderState) checkOutputSize(size int) error {
+	if s.sandbox != nil && s.sandbox.MaxOutputSize > 0 && size > s.sandbox.MaxOutputSize {
+		return &Error{
+			Sender:    "execution",
+			OrigError: outputSizeError(s.sandbox),
+		}
+	}
+	return nil
+}
+
+// enterComponent increments the depth of the nested components, and returns an error if it exceeds the limit.
+// Call leaveComponent after executing the component.
+func (ctx *ExecutionContext) enterComponent(token *Token) *Error {
+	sandbox := ctx.sandbox()
+	if sandbox == nil {
+		return nil
+	}
+	if sandbox.MaxComponentDepth > 0 && ctx.render.componentDepth >= sandbox.MaxComponentDepth {
+		return ctx.OrigError(&SandboxError{
+			Violation: SandboxComponentDepth,
+			Message:   fmt.Sprintf("maximum nested component depth reached (max is %d)", sandbox.MaxComponentDepth),
+		}, token)
+	}
+	ctx.render.componentDepth++
+	return nil
+}
+
+func (ctx *ExecutionContext) leaveComponent() {
+	if ctx.sandbox() != nil {
+		ctx.render.componentDepth--
+	}
+}
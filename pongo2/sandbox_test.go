@@ -0,0 +1,144 @@
+package pongo2
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type sandboxUser struct {
+	Name     string
+	password string
+}
+
+func TestSandbox(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	set.Sandbox = NewSandboxPolicy()
+	set.Sandbox.AllowedMethods = []string{"url.URL.Hostname", "time.Time.*"}
+	set.Sandbox.MaxLoopIterations = 10
+	set.Sandbox.MaxOutputSize = 100
+	set.Sandbox.MaxMacroDepth = 5
+	set.Sandbox.MaxComponentDepth = 2
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "box",
+		TemplateString: `[{{ slot }}]`,
+	})
+
+	ctx := Context{
+		"user":  &sandboxUser{Name: "john", password: "secret"},
+		"users": []sandboxUser{{Name: "a"}, {Name: "b"}},
+		"u":     &url.URL{Scheme: "https", Host: "example.com:8080", RawQuery: "a=1"},
+		"t":     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"file":  os.Stdin,
+		"ch":    make(chan int),
+		"long":  strings.Repeat("x", 101),
+		"upper": strings.ToUpper,
+		"m":     map[string]any{"safe": "ok", "file": os.Stdin},
+		"s":     []any{"ok", []any{make(chan int)}},
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		tests := []struct {
+			template string
+			output   string
+		}{
+			{`{{ user.Name }} {{ u.Hostname() }}`, "john example.com"},
+			{`{{ t.Year() }} {{ t.Format("2006") }}`, "2024 2024"},
+			{`{{ upper(user.Name) }}`, "JOHN"},
+			{`{% for u in users %}{{ u.Name }}{{ forloop.Counter }}{% endfor %}`, "a1b2"},
+			{`{% for i in "123" %}{% for j in "12" %}{{ j }}{% endfor %}{% endfor %}`, "121212"},
+			{`{% component "box" %}{% component "box" %}x{% endcomponent %}{% endcomponent %}`, "[[x]]"},
+			{`{% macro f(n) %}{% if n > 0 %}{{ f(n - 1) }}{% endif %}{{ n }}{% endmacro %}{{ f(4) }}`, "01234"},
+			{`{{ m.safe }} {{ s.0 }}`, "ok ok"},
+		}
+		for _, tt := range tests {
+			out, err := set.RenderTemplateString(tt.template, ctx)
+			assert.NoError(t, err, tt.template)
+			assert.Equal(t, tt.output, out, tt.template)
+		}
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		tests := []struct {
+			template  string
+			violation SandboxViolation
+			err       string
+		}{
+			{`{{ u.Port() }}`, SandboxMethodNotAllowed, "calling the method 'Port' of *url.URL is not allowed (variable u.Port)"},
+			{`{{ user.password }}`, SandboxUnexportedField, "access to the unexported field 'password' of pongo2.sandboxUser is not allowed"},
+			{`{{ user["password"] }}`, SandboxUnexportedField, "access to the unexported field 'password'"},
+			{`{{ file.Name() }}`, SandboxTypeBlocked, "access to 'file' of type os.File is not allowed (variable file.Name)"},
+			{`{{ ch }}`, SandboxTypeBlocked, "access to 'ch' of type chan int is not allowed"},
+			{`{{ m }}`, SandboxTypeBlocked, "access to 'm' of type os.File is not allowed"},
+			{`{{ m|stringformat:"%v" }}`, SandboxTypeBlocked, "access to 'm' of type os.File is not allowed"},
+			{`{{ s }}`, SandboxTypeBlocked, "access to 's' of type chan int is not allowed"},
+			{`{% for i in "1234" %}{% for j in "12" %}{% endfor %}{% endfor %}`, SandboxLoopIterations, "the loops exceeded the maximum number of iterations (max is 10)"},
+			{`{{ long }}`, SandboxOutputSize, "the output exceeded the maximum size (max is 100 bytes)"},
+			{`{% for i in "1234567" %}{{ "1234567890123456" }}{% endfor %}`, SandboxOutputSize, "the output exceeded the maximum size"},
+			{`{% macro f(n) %}{{ f(n + 1) }}{% endmacro %}{{ f(0) }}`, SandboxMacroDepth, "maximum recursive macro call depth reached (max is 5)"},
+			{`{% component "box" %}{% component "box" %}{% component "box" %}x{% endcomponent %}{% endcomponent %}{% endcomponent %}`, SandboxComponentDepth, "maximum nested component depth reached (max is 2)"},
+		}
+		for _, tt := range tests {
+			_, err := set.RenderTemplateString(tt.template, ctx)
+			assert.ErrorContains(t, err, tt.err, tt.template)
+			var sandboxErr *SandboxError
+			if assert.True(t, errors.As(err, &sandboxErr), tt.template) {
+				assert.Equal(t, tt.violation, sandboxErr.Violation, tt.template)
+			}
+		}
+	})
+
+	t.Run("class component methods", func(t *testing.T) {
+		set := NewSet("test", NewFSLoader(fstest.MapFS{
+			"alert.html": {Data: []byte(`{{ Upper(message) }}{% if message == "e" %}{{ IsError() }}{% endif %}`)},
+		}))
+		set.Sandbox = NewSandboxPolicy()
+		set.Sandbox.AllowedMethods = []string{"pongo2.testAlertComponent.Upper"}
+		set.ComponentSet.RegisterClassComponent(&ClassComponentDefinition{
+			Name: "alert",
+			New: func() ClassComponent {
+				return &testAlertComponent{}
+			},
+		})
+
+		out, err := set.RenderTemplateString(`{% component "alert" withAttrs "message"="a" %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "[a]", out)
+
+		_, err = set.RenderTemplateString(`{% component "alert" withAttrs "message"="e" %}{% endcomponent %}`, nil)
+		assert.ErrorContains(t, err, "calling the method 'IsError' of *pongo2.testAlertComponent is not allowed")
+		var sandboxErr *SandboxError
+		if assert.True(t, errors.As(err, &sandboxErr)) {
+			assert.Equal(t, SandboxMethodNotAllowed, sandboxErr.Violation)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		set.Sandbox = NewSandboxPolicy()
+		set.Sandbox.Timeout = 20 * time.Millisecond
+		sleep := func() string {
+			time.Sleep(5 * time.Millisecond)
+			return ""
+		}
+		_, err := set.RenderTemplateString(`{% for i in "1234567890" %}{{ sleep() }}{% endfor %}`, Context{"sleep": sleep})
+		var sandboxErr *SandboxError
+		if assert.True(t, errors.As(err, &sandboxErr)) {
+			assert.Equal(t, SandboxTimeout, sandboxErr.Violation)
+		}
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		out, err := set.RenderTemplateString(`{{ u.Port() }} {{ file.Name() }}`, ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "8080 /dev/stdin", out)
+	})
+}
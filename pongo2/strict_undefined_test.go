@@ -0,0 +1,61 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStrictUndefined(t *testing.T) {
+	type user struct {
+		Name    string
+		Manager *user
+	}
+
+	set := NewSet("test", &DummyLoader{})
+	set.StrictUndefined = true
+	ctx := Context{
+		"nil_value": nil,
+		"user":      &user{Name: "john"},
+		"dict":      map[string]any{"a": nil, "b": "x"},
+		"list":      []int{1, 2},
+	}
+
+	t.Run("defined values", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{{ user.Name }} {{ dict.a }}{{ dict.b }} {{ list.1 }} {{ nil_value }}`, ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "john x 2 ", out)
+	})
+
+	t.Run("undefined values", func(t *testing.T) {
+		tests := []struct {
+			template string
+			err      string
+		}{
+			{"{{ usr.Name }}", `'usr' is undefined (variable usr.Name)`},
+			{"{{ user.Nmae }}", `'user.Nmae' is undefined (variable user.Nmae)`},
+			{"{{ dict.c }}", `'dict.c' is undefined (variable dict.c)`},
+			{"{{ list.5 }}", `'list.5' is undefined (variable list.5)`},
+			{"{{ user.Manager.Name }}", `can't access 'Name' on nil 'user.Manager' (variable user.Manager.Name)`},
+			{"{{ dict.a.x }}", `can't access 'x' on nil 'dict.a' (variable dict.a.x)`},
+			{"{{ nil_value.x }}", `can't access 'x' on nil 'nil_value' (variable nil_value.x)`},
+			{"line1\n{% if missing %}{% endif %}", `[Error (where: execution) in <string> | Line 2 Col 7 near 'missing'] 'missing' is undefined (variable missing)`},
+		}
+		for _, tt := range tests {
+			_, err := set.RenderTemplateString(tt.template, ctx)
+			assert.ErrorContains(t, err, tt.err, tt.template)
+		}
+	})
+
+	t.Run("allowing undefined values", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{{ usr.Name|default:"guest" }} {{ user.Nmae|default_if_none:"none" }} {{ dict.c ?? "c" }} {{ missing is defined }} {{ usr.Name is defined }} {{ list.5 is undefined }}`, ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "guest none c False False True", out)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		out, err := set.RenderTemplateString(`{{ usr.Name }}{{ user.Nmae }}{{ list.5 }}`, ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "", out)
+	})
+}
@@ -0,0 +1,157 @@
+package pongo2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// cache tag
+// Usage:
+// {% cache "sidebar" %}...{% endcache %}
+// {% cache "sidebar" user.ID lang timeout=300 %}...{% endcache %}
+//
+// The rendered content is stored in the Cache of the template set.
+// The cache key varies by the key name, the evaluated expressions, the template and the position of the tag in it.
+// The timeout is a positive number of seconds. Without it, the content is cached until it is evicted or invalidated.
+// The content pushed to the stacks in the block and the stacks declared in it are cached as well, and replayed on a cache hit.
+
+// fragmentCacheKeyPrefix is the prefix of the cache keys of the cache tag.
+const fragmentCacheKeyPrefix = "fragment:"
+
+type tagCacheNode struct {
+	position *Token
+	// template identifies the template that has the tag
+	template string
+	key      string
+	varyBy   []IEvaluator
+	timeout  IEvaluator
+	wrapper  *NodeWrapper
+}
+
+func (node *tagCacheNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	cache := ctx.template.set.Cache
+	if cache == nil || ctx.render == nil {
+		// caching is disabled
+		return node.wrapper.Execute(ctx, writer)
+	}
+
+	var ttl time.Duration
+	if node.timeout != nil {
+		val, err := node.timeout.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkCacheTimeout(val); err != nil {
+			return ctx.Error(err.Error(), node.position)
+		}
+		ttl = time.Duration(val.Float() * float64(time.Second))
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s:%d:%d\n", node.template, node.position.Line, node.position.Col)
+	for _, expr := range node.varyBy {
+		val, err := expr.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\n", cacheKeyValue(val))
+	}
+	key := fragmentCacheKeyPrefix + node.key + ":" + hex.EncodeToString(h.Sum(nil))
+
+	if entry, ok := cache.Get(key); ok {
+		if r, ok := decodeRecording(entry); ok {
+			ctx.render.replay(r, writer)
+			return nil
+		}
+	}
+
+	// The pushes and the stacks in the block are stored with the content, and replayed on a cache hit.
+	r, err := ctx.render.record(func(w TemplateWriter) *Error {
+		return node.wrapper.Execute(ctx, w)
+	})
+	if err != nil {
+		return err
+	}
+	entry, err2 := encodeRecording(r)
+	if err2 != nil {
+		return ctx.OrigError(err2, node.position)
+	}
+	cache.Set(key, entry, ttl)
+	writer.WriteString(r.Output)
+	return nil
+}
+
+// checkCacheTimeout returns an error if the timeout of the cache tag is not a positive number of seconds.
+func checkCacheTimeout(val *Value) error {
+	if !val.IsNumber() || val.Float() <= 0 {
+		return fmt.Errorf("cache tag timeout must be a positive number of seconds, got '%s'", val.String())
+	}
+	return nil
+}
+
+func tagCacheParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	cacheNode := &tagCacheNode{position: start}
+	if doc.template.isTplString {
+		// A template string has no name, so it is identified by its source.
+		sum := sha256.Sum256([]byte(doc.template.tpl))
+		cacheNode.template = "<string>:" + hex.EncodeToString(sum[:])
+	} else {
+		cacheNode.template = doc.template.name
+	}
+
+	keyToken := arguments.MatchType(TokenString)
+	if keyToken == nil {
+		return nil, arguments.Error("cache tag needs a key name as first argument.", nil)
+	}
+	cacheNode.key = keyToken.Val
+
+	for arguments.Remaining() > 0 {
+		if arguments.Peek(TokenIdentifier, "timeout") != nil && arguments.PeekN(1, TokenSymbol, "=") != nil {
+			arguments.ConsumeN(2)
+			timeout, err := arguments.ParseExpression()
+			if err != nil {
+				return nil, err
+			}
+			// A literal timeout is checked at parse time.
+			if lit, ok := literalValue(timeout); ok {
+				if err := checkCacheTimeout(lit); err != nil {
+					return nil, arguments.Error(err.Error(), nil)
+				}
+			}
+			cacheNode.timeout = timeout
+			continue
+		}
+
+		expr, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		cacheNode.varyBy = append(cacheNode.varyBy, expr)
+	}
+
+	wrapper, endtagargs, err := doc.WrapUntilTag("endcache")
+	if err != nil {
+		return nil, err
+	}
+	if endtagargs.Count() > 0 {
+		return nil, endtagargs.Error("Arguments not allowed here.", nil)
+	}
+	cacheNode.wrapper = wrapper
+
+	return cacheNode, nil
+}
+
+// InvalidateFragmentCache removes the content cached by the cache tags whose key names start with the prefix.
+//
+//	set.InvalidateFragmentCache("sidebar")
+func (set *TemplateSet) InvalidateFragmentCache(prefix string) {
+	if set.Cache != nil {
+		set.Cache.DeletePrefix(fragmentCacheKeyPrefix + prefix)
+	}
+}
+
+func init() {
+	RegisterTag("cache", tagCacheParser)
+}
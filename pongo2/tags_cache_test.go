@@ -0,0 +1,93 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCacheTag(t *testing.T) {
+	set := NewSet("test", NewFSLoader(fstest.MapFS{
+		"a.html": &fstest.MapFile{Data: []byte(`{% cache "sidebar" %}{{ fn() }}{% endcache %}`)},
+		"b.html": &fstest.MapFile{Data: []byte(`{% cache "sidebar" %}{{ fn() }}{% endcache %}`)},
+	}))
+	calls := 0
+	fn := func() int {
+		calls++
+		return calls
+	}
+	render := func(src string, ctx Context) string {
+		out, err := set.RenderTemplateString(src, ctx)
+		assert.NoError(t, err)
+		return out
+	}
+
+	t.Run("cached by key and expressions", func(t *testing.T) {
+		calls = 0
+		src := `{% cache "user" name lang timeout=60 %}{{ name }}:{{ fn() }}{% endcache %}`
+		assert.Equal(t, "alice:1", render(src, Context{"fn": fn, "name": "alice", "lang": "en"}))
+		assert.Equal(t, "alice:1", render(src, Context{"fn": fn, "name": "alice", "lang": "en"}))
+		assert.Equal(t, "alice:2", render(src, Context{"fn": fn, "name": "alice", "lang": "ja"}))
+		assert.Equal(t, "bob:3", render(src, Context{"fn": fn, "name": "bob", "lang": "en"}))
+
+		set.InvalidateFragmentCache("user")
+		assert.Equal(t, "alice:4", render(src, Context{"fn": fn, "name": "alice", "lang": "en"}))
+	})
+
+	t.Run("varies by template", func(t *testing.T) {
+		calls = 0
+		for _, name := range []string{"a.html", "b.html", "a.html"} {
+			tpl, err := set.FromCache(name)
+			assert.NoError(t, err)
+			_, err = tpl.Execute(Context{"fn": fn})
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("concurrent renders", func(t *testing.T) {
+		tpl, err := set.FromString(`{% cache "concurrent" n %}{{ n }}{% endcache %}`)
+		assert.NoError(t, err)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				out, err := tpl.Execute(Context{"n": n % 5})
+				assert.NoError(t, err)
+				assert.Equal(t, AsValue(n%5).String(), out)
+			}(i)
+		}
+		wg.Wait()
+	})
+
+	t.Run("stacks and pushes", func(t *testing.T) {
+		calls = 0
+		src := `<head>{% cache "head" %}{% stack "js" %}{% endcache %}</head>{% cache "body" %}{% push "js" %}<script src="/a.js">{% endpush %}{% pushonce "js" "b" %}<script src="/b.js">{% endpushonce %}{{ fn() }}{% endcache %}{% pushonce "js" "b" %}<script src="/b.js">{% endpushonce %}`
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, `<head><script src="/a.js"><script src="/b.js"></head>1`, render(src, Context{"fn": fn}))
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		for _, src := range []string{
+			`{% cache "t" timeout=0 %}{% endcache %}`,
+			`{% cache "t" timeout="300" %}{% endcache %}`,
+		} {
+			_, err := set.FromString(src)
+			assert.ErrorContains(t, err, "cache tag timeout must be a positive number of seconds", src)
+		}
+		for _, ttl := range []any{-1, "x", nil} {
+			_, err := set.RenderTemplateString(`{% cache "t" timeout=ttl %}{% endcache %}`, Context{"ttl": ttl})
+			assert.ErrorContains(t, err, "cache tag timeout must be a positive number of seconds", ttl)
+		}
+		assert.Equal(t, "x", render(`{% cache "t" timeout=ttl %}x{% endcache %}`, Context{"ttl": 0.5}))
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := set.FromString(`{% cache %}{% endcache %}`)
+		assert.Error(t, err)
+	})
+}
@@ -9,11 +9,17 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 )
 
 type ComponentExecutionContext struct {
 	EchoContext echo.Context
 	Data        Context
+
+	slots        map[string]*Slot
+	templateFile string
+	provided     Context
 }
 
 func (c *ComponentExecutionContext) Bind(out any) error {
@@ -64,13 +70,52 @@ func (c *ComponentExecutionContext) Attributes() *Attributes {
 	return c.Data["attributes"].(*Attributes)
 }
 
+// Slot returns the slot passed by the caller, or nil if the caller does not pass it.
+// The name "slot" is the default slot.
+// The slot content is rendered when it is read for the first time, for example by String or IsEmpty.
+func (c *ComponentExecutionContext) Slot(name string) *Slot {
+	return c.slots[name]
+}
+
+// HasSlot reports whether the caller passes the slot and its content is not empty.
+func (c *ComponentExecutionContext) HasSlot(name string) bool {
+	s, ok := c.slots[name]
+	return ok && !s.IsEmpty()
+}
+
+// Provide provides the value to the descendant components that consume the key by Aware.
+func (c *ComponentExecutionContext) Provide(key string, value any) {
+	c.provided[key] = value
+}
+
+// UseTemplate renders the component with the template file instead of the template of the component.
+func (c *ComponentExecutionContext) UseTemplate(templateFile string) {
+	c.templateFile = templateFile
+}
+
 // component is an internal representation of a component.
 type component struct {
 	Name           string
 	TemplateFile   string
 	TemplateString string
-	Props          []string
+	Props          []*PropSpec
 	Setup          func(*ComponentExecutionContext) error
+	// New creates an instance of the class component
+	New func() ClassComponent
+	// Kind is the kind of the component
+	Kind ComponentKind
+	// FromDirectory is true if the component is registered by scanning a components directory
+	FromDirectory bool
+	// CacheTTL enables caching the rendered output of the component
+	CacheTTL time.Duration
+	// ResolveTemplate chooses the template file per call
+	ResolveTemplate func(*ComponentExecutionContext) (string, error)
+	// Provide are the data keys provided to the descendant components
+	Provide []string
+	// Aware are the keys consumed from the ancestor components with the default values
+	Aware map[string]any
+	// shadowed are the registrations with the same name that are hidden by this component
+	shadowed []*component
 }
 
 type componentSet struct {
@@ -79,12 +124,19 @@ type componentSet struct {
 	DefaultTemplateFileExtension string
 	// registered components
 	components map[string]*component
+	// parsed templates of the components resolved at runtime
+	templates      map[string]*Template
+	templatesMutex sync.RWMutex
+	// templateSet is the template set that parses the templates of the components
+	templateSet *TemplateSet
 }
 
-func newComponentSet() *componentSet {
+func newComponentSet(templateSet *TemplateSet) *componentSet {
 	return &componentSet{
 		TemplateSetFS: nil,
 		components:    make(map[string]*component),
+		templates:     make(map[string]*Template),
+		templateSet:   templateSet,
 	}
 }
 
@@ -92,16 +144,40 @@ type Component struct {
 	Name         string
 	TemplateFile string
 	Props        []string
-	Setup        func(*ComponentExecutionContext) error
+	// PropSpecs declares typed props. It can be used together with Props.
+	PropSpecs []*PropSpec
+	Setup     func(*ComponentExecutionContext) error
+	// CacheTTL enables caching the rendered output by the props, the attributes and the slots.
+	// Zero means no caching. The tag option “cache” takes precedence over it.
+	CacheTTL time.Duration
+	// ResolveTemplate chooses the template file per call, for example by a variant prop or by the request.
+	// It is called after Setup unless Setup calls UseTemplate. An empty name means TemplateFile.
+	// The chosen templates are parsed once and cached unless the template set is in debug mode.
+	ResolveTemplate func(*ComponentExecutionContext) (string, error)
+	// Provide declares the data keys (props or values set by Setup) that the descendant components can consume by Aware.
+	Provide []string
+	// Aware declares the keys that the component consumes from the ancestor components, with the default values.
+	// A value passed by the caller takes precedence over the provided value.
+	Aware map[string]any
 }
 
-func (set *componentSet) RegisterComponent(comp *Component) {
-	set.components[comp.Name] = &component{
-		Name:           comp.Name,
-		TemplateFile:   comp.TemplateFile,
-		TemplateString: "",
-		Props:          comp.Props,
-		Setup:          comp.Setup,
+func (set *componentSet) RegisterComponent(comp *Component) error {
+	return set.register(comp.component())
+}
+
+// component converts the registration to the internal representation.
+func (comp *Component) component() *component {
+	return &component{
+		Name:            comp.Name,
+		TemplateFile:    comp.TemplateFile,
+		TemplateString:  "",
+		Props:           mergePropSpecs(comp.Props, comp.PropSpecs),
+		Setup:           comp.Setup,
+		Kind:            ComponentKindComponent,
+		CacheTTL:        comp.CacheTTL,
+		ResolveTemplate: comp.ResolveTemplate,
+		Provide:         comp.Provide,
+		Aware:           comp.Aware,
 	}
 }
 
@@ -110,14 +186,15 @@ type AnonymousComponent struct {
 	TemplateFile string
 }
 
-func (set *componentSet) RegisterAnonymousComponent(comp *AnonymousComponent) {
-	set.components[comp.Name] = &component{
+func (set *componentSet) RegisterAnonymousComponent(comp *AnonymousComponent) error {
+	return set.register(&component{
 		Name:           comp.Name,
 		TemplateFile:   comp.TemplateFile,
 		TemplateString: "",
 		Props:          nil,
 		Setup:          nil,
-	}
+		Kind:           ComponentKindAnonymous,
+	})
 }
 
 type AnonymousComponentsDirectory struct {
@@ -127,14 +204,34 @@ type AnonymousComponentsDirectory struct {
 
 // RegisterTemplateFileComponentsDirectory registers all template files in the specified directory as components.
 func (set *componentSet) RegisterTemplateFileComponentsDirectory(compDir *AnonymousComponentsDirectory) error {
-	return fs.WalkDir(set.TemplateSetFS, compDir.Dir, func(path string, d fs.DirEntry, err error) error {
+	return set.walkComponentsDirectory(set.TemplateSetFS, compDir.Dir, func(name, templateFile string) error {
+		if compDir.Prefix != "" {
+			name = compDir.Prefix + name
+		}
+
+		return set.register(&component{
+			Name:          name,
+			TemplateFile:  templateFile,
+			Kind:          ComponentKindAnonymous,
+			FromDirectory: true,
+		})
+	})
+}
+
+// ComponentFixturesFileSuffix is the suffix of the fixtures file placed next to a component template, like "alert.fixtures.json".
+// The fixtures files are used by the component gallery, and are not registered as components.
+const ComponentFixturesFileSuffix = ".fixtures.json"
+
+// walkComponentsDirectory calls fn with the component name and the template file for each template file in the directory.
+func (set *componentSet) walkComponentsDirectory(fsys fs.FS, dir string, fn func(name, templateFile string) error) error {
+	return fs.WalkDir(fsys, dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !d.IsDir() {
+		if !d.IsDir() && !strings.HasSuffix(path, ComponentFixturesFileSuffix) {
 			templateFile := strings.TrimPrefix(path, "/")
-			name := strings.TrimPrefix(strings.TrimPrefix(templateFile, compDir.Dir), "/")
+			name := strings.TrimPrefix(strings.TrimPrefix(templateFile, dir), "/")
 
 			// Remove the default template extension from the name, if it is set
 			if set.DefaultTemplateFileExtension != "" && filepath.Ext(name) == set.DefaultTemplateFileExtension {
@@ -143,14 +240,8 @@ func (set *componentSet) RegisterTemplateFileComponentsDirectory(compDir *Anonym
 
 			// Replace the path separator with a dot
 			name = strings.ReplaceAll(filepath.ToSlash(name), "/", ".")
-			if compDir.Prefix != "" {
-				name = compDir.Prefix + name
-			}
 
-			set.RegisterAnonymousComponent(&AnonymousComponent{
-				Name:         name,
-				TemplateFile: templateFile,
-			})
+			return fn(name, templateFile)
 		}
 
 		return nil
@@ -161,33 +252,62 @@ type InlineComponent struct {
 	Name           string
 	TemplateString string
 	Props          []string
-	Setup          func(*ComponentExecutionContext) error
+	// PropSpecs declares typed props. It can be used together with Props.
+	PropSpecs []*PropSpec
+	Setup     func(*ComponentExecutionContext) error
+	// CacheTTL enables caching the rendered output by the props, the attributes and the slots.
+	// Zero means no caching. The tag option “cache” takes precedence over it.
+	CacheTTL time.Duration
+	// Provide declares the data keys (props or values set by Setup) that the descendant components can consume by Aware.
+	Provide []string
+	// Aware declares the keys that the component consumes from the ancestor components, with the default values.
+	// A value passed by the caller takes precedence over the provided value.
+	Aware map[string]any
 }
 
-func (set *componentSet) RegisterInlineComponent(comp *InlineComponent) {
-	set.components[comp.Name] = &component{
+func (set *componentSet) RegisterInlineComponent(comp *InlineComponent) error {
+	return set.register(comp.component())
+}
+
+// component converts the registration to the internal representation.
+func (comp *InlineComponent) component() *component {
+	return &component{
 		Name:           comp.Name,
 		TemplateFile:   "",
 		TemplateString: comp.TemplateString,
-		Props:          comp.Props,
+		Props:          mergePropSpecs(comp.Props, comp.PropSpecs),
 		Setup:          comp.Setup,
+		Kind:           ComponentKindInline,
+		CacheTTL:       comp.CacheTTL,
+		Provide:        comp.Provide,
+		Aware:          comp.Aware,
 	}
 }
 
 type HeadlessComponent struct {
 	Name  string
 	Props []string
-	Setup func(*ComponentExecutionContext) error
+	// PropSpecs declares typed props. It can be used together with Props.
+	PropSpecs []*PropSpec
+	Setup     func(*ComponentExecutionContext) error
+	// Provide declares the data keys (props or values set by Setup) that the descendant components can consume by Aware.
+	Provide []string
+	// Aware declares the keys that the component consumes from the ancestor components, with the default values.
+	// A value passed by the caller takes precedence over the provided value.
+	Aware map[string]any
 }
 
-func (set *componentSet) RegisterHeadlessComponent(comp *HeadlessComponent) {
-	set.components[comp.Name] = &component{
+func (set *componentSet) RegisterHeadlessComponent(comp *HeadlessComponent) error {
+	return set.register(&component{
 		Name:           comp.Name,
 		TemplateFile:   "",
 		TemplateString: "{{ slot }}",
-		Props:          comp.Props,
+		Props:          mergePropSpecs(comp.Props, comp.PropSpecs),
 		Setup:          comp.Setup,
-	}
+		Kind:           ComponentKindHeadless,
+		Provide:        comp.Provide,
+		Aware:          comp.Aware,
+	})
 }
 
 func (set *componentSet) resolveComponent(name string) *component {
@@ -197,14 +317,74 @@ func (set *componentSet) resolveComponent(name string) *component {
 	return nil
 }
 
+// parseComponentTemplate parses the template of the component.
+func (set *TemplateSet) parseComponentTemplate(comp *component) (*Template, error) {
+	if comp.TemplateFile != "" {
+		// Load the template from the file system
+		return set.FromFile(comp.TemplateFile)
+	} else if comp.TemplateString != "" {
+		// Load the template from the string
+		return set.FromString(comp.TemplateString)
+	}
+	return nil, fmt.Errorf("component '%s' has no template.", comp.Name)
+}
+
+// cachedComponentTemplate returns the parsed template of the component resolved at runtime.
+// The parsed templates are cached by the component name unless the template set is in debug mode.
+func (set *TemplateSet) cachedComponentTemplate(comp *component) (*Template, error) {
+	if set.Debug {
+		return set.parseComponentTemplate(comp)
+	}
+
+	cs := set.ComponentSet
+	cs.templatesMutex.RLock()
+	tpl, ok := cs.templates[comp.Name]
+	cs.templatesMutex.RUnlock()
+	if ok {
+		return tpl, nil
+	}
+
+	tpl, err := set.parseComponentTemplate(comp)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.templatesMutex.Lock()
+	cs.templates[comp.Name] = tpl
+	cs.templatesMutex.Unlock()
+	return tpl, nil
+}
+
+// componentProps returns the props of the component.
+// The props declared by the component take precedence over the props declared by the {% props %} tag in the template.
+func componentProps(comp *component, tpl *Template) []*PropSpec {
+	if len(comp.Props) > 0 {
+		// get from component
+		return comp.Props
+	} else if len(tpl.props) > 0 {
+		// get from template {% props %} tag
+		return tpl.props
+	}
+	return nil
+}
+
 type tagComponentNode struct {
-	id        string
+	id       string
+	position *Token
+	// nameExpr is the expression of the component name resolved at runtime (dynamic component)
+	nameExpr  IEvaluator
 	tpl       *Template
 	component *component
+	props     []*PropSpec
 	attrs     []*tagComponentAttribute
 	data      map[string]IEvaluator
 	slots     []*componentSlot
 	slotData  *slotData
+	// cacheExpr is the cache TTL in seconds given by the tag option: {% component "nav" cache=300 %}
+	cacheExpr IEvaluator
+	// provide and aware are the keys provided to the descendant components and consumed from the ancestor components
+	provide []string
+	aware   []string
 }
 
 type tagComponentAttribute struct {
@@ -213,8 +393,11 @@ type tagComponentAttribute struct {
 }
 
 type componentSlot struct {
-	Name    string
-	wrapper *NodeWrapper
+	Name               string
+	args               []string
+	attrs              []*tagComponentAttribute
+	preserveWhitespace bool
+	wrapper            *NodeWrapper
 }
 
 type slotData struct {
@@ -278,7 +461,47 @@ func parseSlotDataExpr(expr string) (*slotData, error) {
 
 var ErrNoComponentContent = errors.New("no component content")
 
+// provideData copies the provided keys of the component data to the provided values.
+func (node *tagComponentNode) provideData(provided Context, data Context) {
+	for _, key := range node.provide {
+		if val, ok := data[key]; ok {
+			provided[key] = val
+		}
+	}
+}
+
+// RedirectError is an error to abort the render and redirect the request.
+// The setup function of a component returns it by Redirect.
+type RedirectError struct {
+	Code int
+	URL  string
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("redirect to '%s' (%d)", e.URL, e.Code)
+}
+
+// Redirect returns a RedirectError.
+//
+//	Setup: func(ctx *pongo2.ComponentExecutionContext) error {
+//		if user == nil {
+//			return pongo2.Redirect(http.StatusFound, "/login")
+//		}
+//		return nil
+//	}
+func Redirect(code int, url string) error {
+	return &RedirectError{Code: code, URL: url}
+}
+
 func (node *tagComponentNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	if node.nameExpr != nil {
+		resolved, err := node.resolveDynamicComponent(ctx)
+		if err != nil {
+			return err
+		}
+		return resolved.Execute(ctx, writer)
+	}
+
 	// create component scope new context
 	newCtx := make(Context)
 
@@ -291,6 +514,36 @@ func (node *tagComponentNode) Execute(ctx *ExecutionContext, writer TemplateWrit
 		newCtx[key] = val
 	}
 
+	// consume the values provided by the ancestor components
+	for _, key := range node.aware {
+		if _, ok := newCtx[key]; ok {
+			// passed by the caller
+			continue
+		}
+		if val, ok := ctx.provided[key]; ok {
+			newCtx[key] = val
+		} else if def, ok := node.component.Aware[key]; ok {
+			newCtx[key] = AsValue(def)
+		}
+	}
+
+	// check the props and apply the default values
+	for _, spec := range node.props {
+		val, ok := newCtx[spec.Name]
+		if !ok {
+			if spec.Default != nil {
+				val = AsValue(spec.Default)
+			} else {
+				continue
+			}
+		}
+		checked, err := spec.check(val.(*Value))
+		if err != nil {
+			return ctx.Error(fmt.Sprintf("component '%s': %v", node.component.Name, err), node.position)
+		}
+		newCtx[spec.Name] = checked
+	}
+
 	// create attributes
 	var attrPairs [][2]string
 	for _, attr := range node.attrs {
@@ -302,12 +555,53 @@ func (node *tagComponentNode) Execute(ctx *ExecutionContext, writer TemplateWrit
 	}
 	newCtx["attributes"] = newAttributes(attrPairs)
 
+	// provide the values to the descendant components
+	// in the component template and in the slots
+	provided := make(Context, len(ctx.provided)+len(node.provide))
+	provided.Update(ctx.provided)
+	node.provideData(provided, newCtx)
+
+	// prepare the slots
+	// The slots are rendered lazily when the component template outputs them.
+	slots := make(map[string]*Slot, len(node.slots))
+	for _, slot := range node.slots {
+		var attrPairs [][2]string
+		for _, attr := range slot.attrs {
+			val, err := attr.expr.Evaluate(ctx)
+			if err != nil {
+				return err
+			}
+			attrPairs = append(attrPairs, [2]string{attr.name, val.String()})
+		}
+		slots[slot.Name] = &Slot{
+			Name:       slot.Name,
+			Attributes: newAttributes(attrPairs),
+			node:       node,
+			ctx:        ctx,
+			data:       newCtx,
+			slot:       slot,
+			provided:   provided,
+		}
+	}
+
+	compCtx := &ComponentExecutionContext{
+		EchoContext: ctx.echoContext,
+		Data:        newCtx,
+		slots:       slots,
+		provided:    provided,
+	}
+
+	// create a new instance of the class component
+	var instance ClassComponent
+	setup := node.component.Setup
+	if node.component.New != nil {
+		instance = node.component.New()
+		setup = instance.Setup
+	}
+
 	// execute the component Setup function
-	if node.component.Setup != nil {
-		err := node.component.Setup(&ComponentExecutionContext{
-			EchoContext: ctx.echoContext,
-			Data:        newCtx,
-		})
+	if setup != nil {
+		err := setup(compCtx)
 		if err != nil {
 			// if the component action returns ErrNoComponentContent, do nothing.
 			if errors.Is(err, ErrNoComponentContent) {
@@ -317,100 +611,257 @@ func (node *tagComponentNode) Execute(ctx *ExecutionContext, writer TemplateWrit
 		}
 	}
 
+	// The setup function may change the provided data
+	node.provideData(provided, newCtx)
+
+	if instance != nil {
+		if r, ok := instance.(ShouldRenderer); ok && !r.ShouldRender(compCtx) {
+			return nil
+		}
+	}
+
+	// The setup function or the template resolver can choose an alternative template
+	if compCtx.templateFile == "" && node.component.ResolveTemplate != nil {
+		templateFile, err := node.component.ResolveTemplate(compCtx)
+		if err != nil {
+			return ctx.OrigError(err, node.position)
+		}
+		compCtx.templateFile = templateFile
+	}
+
 	// copy shared context keys
+	// They are copied before looking up the cache, so the cached output depends on them, like the current user.
 	for _, key := range ctx.template.set.SharedContextKeys {
 		if value, ok := ctx.Public[key]; ok {
 			newCtx[key] = value
 		}
 	}
 
-	// execute the slots
-	for _, slot := range node.slots {
-		slotCtx := NewChildExecutionContext(ctx)
-		if node.slotData != nil {
-			// expose the component data to the slot
-			if node.slotData.name != "" {
-				slotCtx.Private[node.slotData.name] = newCtx
-			} else {
-				// extract specific parameters directly from the component data
-				for _, key := range node.slotData.keys {
-					if key.alias == "" {
-						slotCtx.Private[key.name] = newCtx[key.name]
-					} else {
-						slotCtx.Private[key.alias] = newCtx[key.name]
-					}
+	// look up the cached output
+	var cacheKey string
+	var cacheTTL time.Duration
+	if cache := ctx.template.set.Cache; cache != nil && ctx.render != nil {
+		ttl, err := node.cacheTTLOf(ctx)
+		if err != nil {
+			return err
+		}
+		if ttl > 0 && node.cacheable() {
+			key, err := node.cacheKey(compCtx.templateFile, newCtx, attrPairs, slots)
+			if err != nil {
+				return err
+			}
+			if entry, ok := cache.Get(key); ok {
+				if r, ok := decodeRecording(entry); ok {
+					ctx.render.replay(r, writer)
+					return nil
 				}
 			}
+			cacheKey, cacheTTL = key, ttl
+		}
+	}
+
+	if instance != nil {
+		// make the methods of the class component callable from the template
+		bindClassComponentMethods(instance, newCtx, ctx.sandbox())
+	}
+
+	for _, slot := range node.slots {
+		if len(slot.args) > 0 {
+			// A scoped slot is rendered when the component template calls it with arguments.
+			newCtx[slot.Name] = slots[slot.Name].Render
+		} else {
+			newCtx[slot.Name] = slots[slot.Name]
 		}
+	}
 
+	// The "slot" variable renders the default slot.
+	// Calling it with a slot name and arguments renders the named slot, like: {{ slot("row", item) }}
+	newCtx["slot"] = func(args ...*Value) (*Value, error) {
+		if len(args) == 0 {
+			val, err := slots["slot"].value()
+			if err != nil {
+				return nil, err
+			}
+			return val, nil
+		}
+		s, ok := slots[args[0].String()]
+		if !ok {
+			// The caller did not provide the slot.
+			return AsSafeValue(""), nil
+		}
+		return s.Render(args[1:]...)
+	}
+
+	// The "has_slot" function reports whether the slot is passed and is not empty, like: {% if has_slot("footer") %}
+	newCtx["has_slot"] = func(name string) bool {
+		s, ok := slots[name]
+		return ok && !s.IsEmpty()
+	}
+
+	tpl := node.tpl
+	if compCtx.templateFile != "" {
+		t, err := ctx.template.set.FromCache(compCtx.templateFile)
+		if err != nil {
+			return ctx.OrigError(err, node.position)
+		}
+		tpl = t
+	}
+
+	// Execute the component template
+	execute := func(w TemplateWriter) *Error {
+		tplCtx := NewChildExecutionContext(ctx)
+		tplCtx.provided = provided
+		if err := ctx.enterComponent(node.position); err != nil {
+			return err
+		}
+		err := tpl.executeNested(tplCtx, newCtx, w)
+		ctx.leaveComponent()
+		if err != nil {
+			return err.(*Error)
+		}
+
+		// report an error that occurred in rendering a slot
+		for _, slot := range node.slots {
+			if err := slots[slot.Name].err; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if cacheKey == "" {
 		var b bytes.Buffer
-		if err := slot.wrapper.Execute(slotCtx, &b); err != nil {
+		if err := execute(&b); err != nil {
 			return err
 		}
-		newCtx[slot.Name] = AsSafeValue(strings.TrimSpace(b.String()))
+		writer.WriteString(b.String())
+		return nil
 	}
 
-	// Execute the component template
-	err := node.tpl.ExecuteWriterWithEchoContext(newCtx, writer, ctx.echoContext)
+	// The pushes and the stacks in the component are stored with the output, and replayed on a cache hit.
+	r, err := ctx.render.record(execute)
 	if err != nil {
-		return err.(*Error)
+		return err
 	}
-
+	entry, err2 := encodeRecording(r)
+	if err2 != nil {
+		return ctx.OrigError(err2, node.position)
+	}
+	ctx.template.set.Cache.Set(cacheKey, entry, cacheTTL)
+	writer.WriteString(r.Output)
 	return nil
 }
 
+// resolveDynamicComponent resolves the component by the name evaluated at runtime.
+// It returns a copy of the node with the resolved component, and the passed attributes split into props and attributes.
+func (node *tagComponentNode) resolveDynamicComponent(ctx *ExecutionContext) (*tagComponentNode, *Error) {
+	nameValue, err := node.nameExpr.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := nameValue.String()
+
+	set := ctx.template.set
+	comp := set.ComponentSet.resolveComponent(name)
+	if comp == nil {
+		return nil, ctx.Error(fmt.Sprintf("component '%s' can not be resolved.", name), node.position)
+	}
+	tpl, err2 := set.cachedComponentTemplate(comp)
+	if err2 != nil {
+		return nil, ctx.OrigError(err2, node.position)
+	}
+
+	resolved := *node
+	resolved.nameExpr = nil
+	resolved.component = comp
+	resolved.tpl = tpl
+	resolved.props = componentProps(comp, tpl)
+	resolved.provide = componentProvide(comp, tpl)
+	resolved.aware = componentAware(comp, tpl)
+	resolved.data = make(map[string]IEvaluator)
+	resolved.attrs = make([]*tagComponentAttribute, 0)
+
+	propsMap := make(map[string]*PropSpec)
+	for _, key := range resolved.aware {
+		propsMap[key] = &PropSpec{Name: key}
+	}
+	for _, prop := range resolved.props {
+		propsMap[prop.Name] = prop
+	}
+	for _, attr := range node.attrs {
+		if _, ok := propsMap[attr.name]; ok {
+			resolved.data[attr.name] = attr.expr
+		} else {
+			resolved.attrs = append(resolved.attrs, attr)
+		}
+	}
+
+	// check the required props
+	for _, spec := range resolved.props {
+		if _, ok := resolved.data[spec.Name]; spec.Required && !ok {
+			return nil, ctx.Error(fmt.Sprintf("component '%s': missing required prop '%s'", name, spec.Name), node.position)
+		}
+	}
+
+	return &resolved, nil
+}
+
 // The component tag is like the following:
 // {% component "alert" withAttrs "message"="text" "type"=type %}
 
 func tagComponentParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
 	componentNode := &tagComponentNode{
+		position: start,
 		attrs:    make([]*tagComponentAttribute, 0),
 		data:     make(map[string]IEvaluator),
 		slots:    make([]*componentSlot, 0),
 		slotData: nil,
 	}
+	if doc.template != nil {
+	}
 
+	var componentName string
+	var props []*PropSpec
 	componentNameToken := arguments.MatchType(TokenString)
-	if componentNameToken == nil {
-		return nil, arguments.Error("component tag needs a component name as first argument.", nil)
-	}
-	componentName := componentNameToken.Val
-	comp := doc.template.set.ComponentSet.resolveComponent(componentName)
-	if comp == nil {
-		return nil, arguments.Error(fmt.Sprintf("component '%s' can not be resolved.", componentName), nil)
-	}
-	componentNode.component = comp
+	if componentNameToken != nil {
+		componentName = componentNameToken.Val
+		comp := doc.template.set.ComponentSet.resolveComponent(componentName)
+		if comp == nil {
+			return nil, arguments.Error(fmt.Sprintf("component '%s' can not be resolved.", componentName), nil)
+		}
+		componentNode.component = comp
 
-	if comp.TemplateFile != "" {
-		// Load the template from the file system
-		tpl, err := doc.template.set.FromFile(comp.TemplateFile)
+		tpl, err := doc.template.set.parseComponentTemplate(comp)
 		if err != nil {
-			return nil, err.(*Error)
+			if e, ok := err.(*Error); ok {
+				return nil, e
+			}
+			return nil, arguments.Error(err.Error(), nil)
 		}
 		componentNode.tpl = tpl
-	} else if comp.TemplateString != "" {
-		// Load the template from the string
-		tpl, err := doc.template.set.FromString(comp.TemplateString)
+
+		// get props definition
+		props = componentProps(comp, tpl)
+		componentNode.provide = componentProvide(comp, tpl)
+		componentNode.aware = componentAware(comp, tpl)
+	} else {
+		// The component name is an expression resolved at runtime:
+		// {% component block.type withAttrs "title"=block.title %}
+		componentNameToken = arguments.Current()
+		nameExpr, err := arguments.ParseExpression()
 		if err != nil {
-			return nil, err.(*Error)
+			return nil, arguments.Error("component tag needs a component name as first argument.", nil)
 		}
-		componentNode.tpl = tpl
-	} else {
-		return nil, arguments.Error(fmt.Sprintf("component '%s' has no template.", componentName), nil)
+		componentNode.nameExpr = nameExpr
 	}
-
-	// get props definition:
-	var props []string
-	if len(comp.Props) > 0 {
-		// get from component
-		props = comp.Props
-	} else if len(componentNode.tpl.props) > 0 {
-		// get from template {% props %} tag
-		props = componentNode.tpl.props
+	componentNode.props = props
+	propsMap := make(map[string]*PropSpec)
+	// The keys consumed from the ancestor components can also be passed by the caller
+	for _, key := range componentNode.aware {
+		propsMap[key] = &PropSpec{Name: key}
 	}
-	propsMap := make(map[string]bool)
 	for _, prop := range props {
-		propsMap[prop] = true
+		propsMap[prop.Name] = prop
 	}
 
 	// After having parsed the component name we're going to parse the additional options
@@ -432,6 +883,18 @@ func tagComponentParser(doc *Parser, start *Token, arguments *Parser) (INodeTag,
 		componentNode.slotData = sd
 	}
 
+	// cache option
+	if arguments.Match(TokenIdentifier, "cache") != nil {
+		if arguments.Match(TokenSymbol, "=") == nil {
+			return nil, arguments.Error("Expected '='.", nil)
+		}
+		cacheExpr, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		componentNode.cacheExpr = cacheExpr
+	}
+
 	// with options
 	if arguments.Match(TokenIdentifier, "withAttrs") != nil {
 		for arguments.Remaining() > 0 {
@@ -449,8 +912,14 @@ func tagComponentParser(doc *Parser, start *Token, arguments *Parser) (INodeTag,
 			}
 
 			// Check if the key is a prop or a fallthrough attribute
-			if propsMap[keyToken.Val] {
+			if spec, ok := propsMap[keyToken.Val]; ok {
 				// the key is a prop
+				// If the value is a literal, we can check it at parse time.
+				if lit, ok := literalValue(valueExpr); ok {
+					if _, err := spec.check(lit); err != nil {
+						return nil, arguments.Error(fmt.Sprintf("component '%s': %v", componentName, err), keyToken)
+					}
+				}
 				componentNode.data[keyToken.Val] = valueExpr
 			} else {
 				// the key is a fallthrough attribute
@@ -469,6 +938,13 @@ func tagComponentParser(doc *Parser, start *Token, arguments *Parser) (INodeTag,
 		return nil, arguments.Error("Malformed 'component'-tag arguments.", nil)
 	}
 
+	// check the required props
+	for _, spec := range props {
+		if _, ok := componentNode.data[spec.Name]; spec.Required && !ok {
+			return nil, arguments.Error(fmt.Sprintf("component '%s': missing required prop '%s'", componentName, spec.Name), componentNameToken)
+		}
+	}
+
 	for {
 		wrapper, tagArgs, err := doc.WrapUntilTag("slot", "endcomponent")
 		if err != nil {
@@ -481,6 +957,49 @@ func tagComponentParser(doc *Parser, start *Token, arguments *Parser) (INodeTag,
 				return nil, tagArgs.Error("slot tag needs a slot name as first argument.", nil)
 			}
 
+			slot := &componentSlot{Name: slotNameToken.Val}
+
+			// scoped slot arguments: {% slot "row" args item, index %}
+			if tagArgs.Match(TokenIdentifier, "args") != nil {
+				for {
+					argToken := tagArgs.MatchType(TokenIdentifier)
+					if argToken == nil {
+						return nil, tagArgs.Error("Expected an argument name (identifier).", nil)
+					}
+					slot.args = append(slot.args, argToken.Val)
+					if tagArgs.Match(TokenSymbol, ",") == nil {
+						break
+					}
+				}
+			}
+
+			// keep the leading and trailing whitespace: {% slot "code" preserveWhitespace %}
+			if tagArgs.Match(TokenIdentifier, "preserveWhitespace") != nil {
+				slot.preserveWhitespace = true
+			}
+
+			// slot attributes: {% slot "footer" withAttrs "class"="mt-4" %}
+			if tagArgs.Match(TokenIdentifier, "withAttrs") != nil {
+				for tagArgs.Remaining() > 0 {
+					keyToken := tagArgs.MatchType(TokenString)
+					if keyToken == nil {
+						return nil, tagArgs.Error("Expected an identifier", nil)
+					}
+					if tagArgs.Match(TokenSymbol, "=") == nil {
+						return nil, tagArgs.Error("Expected '='.", nil)
+					}
+					valueExpr, err := tagArgs.ParseExpression()
+					if err != nil {
+						return nil, err
+					}
+					slot.attrs = append(slot.attrs, &tagComponentAttribute{name: keyToken.Val, expr: valueExpr})
+				}
+			}
+
+			if tagArgs.Remaining() > 0 {
+				return nil, tagArgs.Error("Malformed 'slot'-tag arguments.", nil)
+			}
+
 			wrapper, tagArgs, err := doc.WrapUntilTag("endslot")
 			if err != nil {
 				return nil, err
@@ -488,7 +1007,8 @@ func tagComponentParser(doc *Parser, start *Token, arguments *Parser) (INodeTag,
 			if tagArgs.Count() > 0 {
 				return nil, tagArgs.Error("Arguments not allowed here.", nil)
 			}
-			componentNode.slots = append(componentNode.slots, &componentSlot{Name: slotNameToken.Val, wrapper: wrapper})
+			slot.wrapper = wrapper
+			componentNode.slots = append(componentNode.slots, slot)
 		} else if wrapper.Endtag == "endcomponent" {
 			if tagArgs.Count() > 0 {
 				return nil, tagArgs.Error("Arguments not allowed here.", nil)
@@ -1,8 +1,14 @@
 package pongo2
 
 import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestParseSlotDataExpr(t *testing.T) {
@@ -85,3 +91,536 @@ func TestParseSlotDataExpr(t *testing.T) {
 		}
 	}
 }
+
+func TestComponentPropSpecs(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "counter",
+		TemplateString: `{{ label }}:{{ count + 1 }}`,
+		PropSpecs: []*PropSpec{
+			{Name: "label", Type: PropTypeString, Required: true},
+			{Name: "count", Type: PropTypeInt, Default: 10, Validator: func(v *Value) error {
+				if v.Integer() < 0 {
+					return errors.New("must not be negative")
+				}
+				return nil
+			}},
+		},
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "badge",
+		TemplateString: `{% props text:string required, size:int=2 %}{{ text }}/{{ size * 2 }}`,
+	})
+
+	t.Run("convert and default", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "counter" withAttrs "label"="a" "count"="3" %}{% endcomponent %} {% component "counter" withAttrs "label"="b" %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "a:4 b:11", out)
+	})
+
+	t.Run("props tag", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "badge" withAttrs "text"="x" %}{% endcomponent %} {% component "badge" withAttrs "text"="y" "size"="5" %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "x/4 y/10", out)
+	})
+
+	t.Run("parse time errors", func(t *testing.T) {
+		_, err := set.FromString(`{% component "counter" withAttrs "count"=1 %}{% endcomponent %}`)
+		assert.ErrorContains(t, err, "component 'counter': missing required prop 'label'")
+
+		_, err = set.FromString(`{% component "counter" withAttrs "label"="a" "count"="abc" %}{% endcomponent %}`)
+		assert.ErrorContains(t, err, "prop 'count' must be of type int")
+
+		_, err = set.FromString(`{% component "counter" withAttrs "label"="a" "count"="-1" %}{% endcomponent %}`)
+		assert.ErrorContains(t, err, "prop 'count' is invalid: must not be negative")
+
+		_, err = set.FromString(`{% component "badge" %}{% endcomponent %}`)
+		assert.ErrorContains(t, err, "component 'badge': missing required prop 'text'")
+	})
+
+	t.Run("execution time errors", func(t *testing.T) {
+		tpl, err := set.FromString(`{% component "counter" withAttrs "label"="a" "count"=n %}{% endcomponent %}`)
+		assert.NoError(t, err)
+		_, err = tpl.Execute(Context{"n": "abc"})
+		assert.ErrorContains(t, err, "Line 1 Col 4")
+		assert.ErrorContains(t, err, "component 'counter': prop 'count' must be of type int")
+	})
+}
+
+func TestComponentScopedSlotArgs(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "list",
+		Props:          []string{"items"},
+		TemplateString: `<ul>{% for item in items %}<li>{{ slot("row", item, forloop.Counter) }}</li>{% endfor %}</ul>{{ slot }}`,
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "single",
+		TemplateString: `[{{ row("a") }}]`,
+	})
+
+	t.Run("slot call with arguments", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "list" withAttrs "items"=items %}{% slot "row" args item, index %}{{ index }}:{{ item }}{% endslot %}footer{% endcomponent %}`, Context{"items": []string{"x", "<y>"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "<ul><li>1:x</li><li>2:&lt;y&gt;</li></ul>footer", out)
+	})
+
+	t.Run("missing arguments and slots", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "single" %}{% slot "row" args a, b %}{{ a }}{{ b|default:"-" }}{% endslot %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "[a-]", out)
+
+		out, err = set.RenderTemplateString(`{% component "list" withAttrs "items"=items %}empty{% endcomponent %}`, Context{"items": []string{"x"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "<ul><li></li></ul>empty", out)
+	})
+
+	t.Run("too many arguments", func(t *testing.T) {
+		_, err := set.RenderTemplateString(`{% component "single" %}{% slot "row" %}{% endslot %}{% endcomponent %}`, nil)
+		assert.Error(t, err)
+
+		_, err = set.RenderTemplateString(`{% component "list" withAttrs "items"=items %}{% slot "row" args item %}{% endslot %}{% endcomponent %}`, Context{"items": []string{"x"}})
+		assert.ErrorContains(t, err, "slot 'row' of component 'list' called with too many arguments (2 instead of 1)")
+	})
+}
+
+func TestComponentLazySlots(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "card",
+		TemplateString: `{% if has_slot("header") %}<h1>{{ header }}</h1>{% endif %}{{ slot }}{% if footer %}<footer {{ footer.Attributes }}>{{ footer }}</footer>{% endif %}`,
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "code",
+		TemplateString: `<pre>{{ code }}</pre>{{ code.IsEmpty() }}`,
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "hidden",
+		TemplateString: `hidden`,
+	})
+
+	t.Run("presence checks and attributes", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "card" %}{% slot "header" %}Title{% endslot %}{% slot "footer" withAttrs "class"="mt-4" %}  {% endslot %}body{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "<h1>Title</h1>body", out)
+
+		out, err = set.RenderTemplateString(`{% component "card" %}{% slot "footer" withAttrs "class"=cls %}Footer{% endslot %}{% endcomponent %}`, Context{"cls": "mt-4"})
+		assert.NoError(t, err)
+		assert.Equal(t, `<footer class="mt-4">Footer</footer>`, out)
+	})
+
+	t.Run("preserve whitespace", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "code" %}{% slot "code" preserveWhitespace %}
+  a
+{% endslot %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "<pre>\n  a\n</pre>False", out)
+	})
+
+	t.Run("slots are rendered only when used", func(t *testing.T) {
+		calls := 0
+		fn := func() string {
+			calls++
+			return "x"
+		}
+		out, err := set.RenderTemplateString(`{% component "hidden" %}{% slot "footer" %}{{ fn() }}{% endslot %}{{ fn() }}{% endcomponent %}`, Context{"fn": fn})
+		assert.NoError(t, err)
+		assert.Equal(t, "hidden", out)
+		assert.Equal(t, 0, calls)
+
+		out, err = set.RenderTemplateString(`{% component "card" %}{% slot "header" %}{{ fn() }}{% endslot %}{% endcomponent %}`, Context{"fn": fn})
+		assert.NoError(t, err)
+		assert.Equal(t, "<h1>x</h1>", out)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("filters on named slots", func(t *testing.T) {
+		set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+			Name:           "filtered",
+			TemplateString: `{{ footer|length }}:{{ footer|upper }}:{{ footer|striptags }}:{{ footer|truncatechars:4 }}`,
+		})
+		out, err := set.RenderTemplateString(`{% component "filtered" %}{% slot "footer" %}<b>Footer</b>{% endslot %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		// the filters receive the rendered content, and their output is escaped like the one of a string
+		assert.Equal(t, "13:&lt;B&gt;FOOTER&lt;/B&gt;:Footer:&lt;...", out)
+	})
+
+	t.Run("slot errors", func(t *testing.T) {
+		_, err := set.RenderTemplateString(`{% component "card" %}{% slot "header" %}{{ fn() }}{% endslot %}{% endcomponent %}`, Context{"fn": func() (string, error) {
+			return "", errors.New("slot failure")
+		}})
+		assert.ErrorContains(t, err, "slot failure")
+	})
+}
+
+func TestDynamicComponent(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "text",
+		TemplateString: `<p {{ attributes }}>{{ body }}</p>`,
+		PropSpecs:      []*PropSpec{{Name: "body", Type: PropTypeString, Required: true}},
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "heading",
+		TemplateString: `{% props body, level:int=1 %}<h{{ level }}>{{ body }}{{ slot }}</h{{ level }}>`,
+	})
+
+	blocks := []map[string]any{
+		{"type": "heading", "body": "Title", "level": "2"},
+		{"type": "text", "body": "Hello"},
+	}
+
+	t.Run("resolve at runtime", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% for block in blocks %}{% component block.type withAttrs "body"=block.body "level"=block.level "class"="mt-4" %}!{% endcomponent %}{% endfor %}`, Context{"blocks": blocks})
+		assert.NoError(t, err)
+		assert.Equal(t, `<h2>Title!</h2><p level="" class="mt-4">Hello</p>`, out)
+	})
+
+	t.Run("unknown component", func(t *testing.T) {
+		_, err := set.RenderTemplateString(`{% component name %}{% endcomponent %}`, Context{"name": "unknown"})
+		assert.ErrorContains(t, err, "component 'unknown' can not be resolved.")
+	})
+
+	t.Run("missing required prop", func(t *testing.T) {
+		_, err := set.RenderTemplateString(`{% component name %}{% endcomponent %}`, Context{"name": "text"})
+		assert.ErrorContains(t, err, "component 'text': missing required prop 'body'")
+	})
+
+	t.Run("template cache", func(t *testing.T) {
+		set := NewSet("test", NewFSLoader(fstest.MapFS{
+			"box.html": {Data: []byte(`<div>{{ body }}</div>`)},
+		}))
+		assert.NoError(t, set.ComponentSet.RegisterAnonymousComponent(&AnonymousComponent{Name: "box", TemplateFile: "box.html"}))
+
+		out, err := set.RenderTemplateString(`{% component name withAttrs "body"="a" %}{% endcomponent %}`, Context{"name": "box"})
+		assert.NoError(t, err)
+		assert.Equal(t, `<div></div>`, out)
+		assert.Contains(t, set.ComponentSet.templates, "box")
+
+		// A component with higher precedence replaces the cached template.
+		assert.NoError(t, set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+			Name:           "box",
+			TemplateString: `<span>{{ body }}</span>`,
+			Props:          []string{"body"},
+		}))
+		assert.NotContains(t, set.ComponentSet.templates, "box")
+		out, err = set.RenderTemplateString(`{% component name withAttrs "body"="a" %}{% endcomponent %}`, Context{"name": "box"})
+		assert.NoError(t, err)
+		assert.Equal(t, "<span>a</span>", out)
+	})
+}
+
+func TestComponentCache(t *testing.T) {
+	set := NewSet("test", NewFSLoader(fstest.MapFS{
+		"nav-compact.html": &fstest.MapFile{Data: []byte(`<small>{{ active }}:{{ render() }}</small>`)},
+	}))
+	setups, renders := 0, 0
+	render := func() int {
+		renders++
+		return renders
+	}
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "nav",
+		TemplateString: `{% props active %}<nav {{ attributes }}>{{ active }}:{{ render() }}:{{ slot }}</nav>`,
+		Setup: func(ctx *ComponentExecutionContext) error {
+			setups++
+			ctx.Set("render", render)
+			switch ctx.Attributes().Get("mode") {
+			case "compact":
+				ctx.UseTemplate("nav-compact.html")
+			case "redirect":
+				return Redirect(302, "/login")
+			}
+			return nil
+		},
+		CacheTTL: time.Minute,
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "counter",
+		TemplateString: `{{ render() }}`,
+		Setup: func(ctx *ComponentExecutionContext) error {
+			ctx.Set("render", render)
+			return nil
+		},
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "theme",
+		TemplateString: `{{ slot }}`,
+		Setup: func(ctx *ComponentExecutionContext) error {
+			ctx.Set("color", "dark")
+			return nil
+		},
+		CacheTTL: time.Minute,
+		Provide:  []string{"color"},
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "swatch",
+		TemplateString: `[{{ color }}]`,
+		Aware:          map[string]any{"color": "none"},
+	})
+
+	renderString := func(t *testing.T, src string) string {
+		out, err := set.RenderTemplateString(src, nil)
+		assert.NoError(t, err)
+		return out
+	}
+
+	t.Run("cached by props, attributes and slots", func(t *testing.T) {
+		setups, renders = 0, 0
+		assert.Equal(t, `<nav >home:1:a</nav>`, renderString(t, `{% component "nav" withAttrs "active"="home" %}a{% endcomponent %}`))
+		assert.Equal(t, `<nav >home:1:a</nav>`, renderString(t, `{% component "nav" withAttrs "active"="home" %}a{% endcomponent %}`))
+		assert.Equal(t, `<nav >about:2:a</nav>`, renderString(t, `{% component "nav" withAttrs "active"="about" %}a{% endcomponent %}`))
+		assert.Equal(t, `<nav class="x">home:3:a</nav>`, renderString(t, `{% component "nav" withAttrs "active"="home" "class"="x" %}a{% endcomponent %}`))
+		assert.Equal(t, `<nav >home:4:b</nav>`, renderString(t, `{% component "nav" withAttrs "active"="home" %}b{% endcomponent %}`))
+		assert.Equal(t, 4, renders)
+		// the setup function runs on a cache hit as well
+		assert.Equal(t, 5, setups)
+
+		set.Cache.DeletePrefix("component:nav:")
+		assert.Equal(t, `<nav >home:5:a</nav>`, renderString(t, `{% component "nav" withAttrs "active"="home" %}a{% endcomponent %}`))
+	})
+
+	t.Run("cache option", func(t *testing.T) {
+		renders = 0
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, "1", renderString(t, `{% component "counter" cache=60 %}{% endcomponent %}`))
+		}
+		assert.Equal(t, `<nav >home:2:a</nav>`, renderString(t, `{% component "nav" cache=0 withAttrs "active"="home" %}a{% endcomponent %}`))
+	})
+
+	t.Run("template chosen by the setup function", func(t *testing.T) {
+		set.Cache.DeletePrefix("component:nav:")
+		renders = 0
+		assert.Equal(t, `<nav mode="">home:1:a</nav>`, renderString(t, `{% component "nav" withAttrs "active"="home" "mode"="" %}a{% endcomponent %}`))
+		assert.Equal(t, `<small>home:2</small>`, renderString(t, `{% component "nav" withAttrs "active"="home" "mode"="compact" %}a{% endcomponent %}`))
+		assert.Equal(t, `<small>home:2</small>`, renderString(t, `{% component "nav" withAttrs "active"="home" "mode"="compact" %}a{% endcomponent %}`))
+	})
+
+	t.Run("setup errors on a cache hit", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			_, err := set.RenderTemplateString(`{% component "nav" withAttrs "mode"="redirect" %}a{% endcomponent %}`, nil)
+			var re *RedirectError
+			assert.True(t, errors.As(err, &re))
+		}
+	})
+
+	t.Run("slots with the values provided by the setup function", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, `[dark]`, renderString(t, `{% component "theme" %}{% component "swatch" %}{% endcomponent %}{% endcomponent %}`))
+		}
+	})
+
+	t.Run("slot contents", func(t *testing.T) {
+		renders = 0
+		src := `{% component "nav" withAttrs "active"="home" %}{{ user.Name }}{% endcomponent %}`
+		for _, name := range []string{"alice", "bob", "alice"} {
+			out, err := set.RenderTemplateString(src, Context{"user": map[string]string{"Name": name}})
+			assert.NoError(t, err)
+			assert.Contains(t, out, ":"+name+"</nav>")
+		}
+		assert.Equal(t, 2, renders)
+	})
+
+	t.Run("shared context values", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		set.SharedContextKeys = []string{"user"}
+		set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+			Name:           "greeting",
+			TemplateString: `Hello {{ user }}`,
+			CacheTTL:       time.Minute,
+		})
+		for _, name := range []string{"alice", "bob"} {
+			out, err := set.RenderTemplateString(`{% component "greeting" %}{% endcomponent %}`, Context{"user": name})
+			assert.NoError(t, err)
+			assert.Equal(t, "Hello "+name, out)
+		}
+	})
+
+	t.Run("props with unexported fields", func(t *testing.T) {
+		type secret struct{ value string }
+		set := NewSet("test", &DummyLoader{})
+		set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+			Name:           "secret",
+			TemplateString: `{{ render() }}`,
+			Props:          []string{"s"},
+			Setup: func(ctx *ComponentExecutionContext) error {
+				ctx.Set("render", render)
+				return nil
+			},
+			CacheTTL: time.Minute,
+		})
+		renders = 0
+		for _, v := range []string{"a", "b", "a"} {
+			_, err := set.RenderTemplateString(`{% component "secret" withAttrs "s"=s %}{% endcomponent %}`, Context{"s": secret{value: v}})
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 2, renders)
+		assert.NotEqual(t, cacheKeyValue(secret{value: "a"}), cacheKeyValue(secret{value: "b"}))
+		assert.Equal(t, cacheKeyValue(map[string]any{"a": 1, "b": &secret{}}), cacheKeyValue(map[string]any{"b": &secret{}, "a": 1}))
+	})
+
+	t.Run("stacks and pushes", func(t *testing.T) {
+		set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+			Name:           "scripts",
+			TemplateString: `{% push "js" %}<script src="/{{ name }}.js">{% endpush %}{{ render() }}`,
+			Props:          []string{"name"},
+			Setup: func(ctx *ComponentExecutionContext) error {
+				ctx.Set("render", render)
+				return nil
+			},
+			CacheTTL: time.Minute,
+		})
+		renders = 0
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, `<head><script src="/app.js"></head>1`, renderString(t, `<head>{% stack "js" %}</head>{% component "scripts" withAttrs "name"="app" %}{% endcomponent %}`))
+		}
+		assert.Equal(t, 1, renders)
+	})
+
+	t.Run("scoped slots are not cached", func(t *testing.T) {
+		set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+			Name:           "list",
+			TemplateString: `{% for item in items %}{{ row(item) }}{% endfor %}`,
+			Props:          []string{"items"},
+			CacheTTL:       time.Minute,
+		})
+		for _, suffix := range []string{"!", "?"} {
+			out, err := set.RenderTemplateString(`{% component "list" withAttrs "items"=items %}{% slot "row" args item %}{{ item }}{{ suffix }}{% endslot %}{% endcomponent %}`, Context{"items": []string{"a"}, "suffix": suffix})
+			assert.NoError(t, err)
+			assert.Equal(t, "a"+suffix, out)
+		}
+	})
+}
+
+func TestComponentSetupContext(t *testing.T) {
+	set := NewSet("test", NewFSLoader(fstest.MapFS{
+		"compact.html": &fstest.MapFile{Data: []byte(`<small>{{ slot }}</small>`)},
+	}))
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "card",
+		TemplateString: `<div>{{ heading }}|{{ has_footer }}|{{ slot }}</div>`,
+		Setup: func(ctx *ComponentExecutionContext) error {
+			ctx.Set("heading", strings.ToUpper(ctx.Slot("title").String()))
+			ctx.Set("has_footer", ctx.HasSlot("footer"))
+			if ctx.Slot("missing") != nil {
+				return errors.New("unexpected slot")
+			}
+			if ctx.Attributes().Has("compact") {
+				ctx.UseTemplate("compact.html")
+			}
+			return nil
+		},
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "guard",
+		TemplateString: `guarded`,
+		Setup: func(ctx *ComponentExecutionContext) error {
+			switch ctx.Attributes().Get("mode") {
+			case "notfound":
+				return echo.ErrNotFound
+			case "redirect":
+				return Redirect(302, "/login")
+			}
+			return nil
+		},
+	})
+
+	t.Run("slots", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "card" %}{% slot "title" %}hello{% endslot %}{% slot "footer" %} {% endslot %}body{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "<div>HELLO|False|body</div>", out)
+	})
+
+	t.Run("alternative template", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "card" withAttrs "compact"=true %}{% slot "title" %}hello{% endslot %}body{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "<small>body</small>", out)
+	})
+
+	t.Run("typed errors", func(t *testing.T) {
+		_, err := set.RenderTemplateString(`{% component "guard" withAttrs "mode"="notfound" %}{% endcomponent %}`, nil)
+		var he *echo.HTTPError
+		assert.True(t, errors.As(err, &he))
+		assert.Equal(t, 404, he.Code)
+
+		_, err = set.RenderTemplateString(`{% component "card" %}{% slot "title" %}{% component "guard" withAttrs "mode"="redirect" %}{% endcomponent %}{% endslot %}{% endcomponent %}`, nil)
+		var re *RedirectError
+		assert.True(t, errors.As(err, &re))
+		assert.Equal(t, "/login", re.URL)
+	})
+}
+
+func TestComponentResolveTemplate(t *testing.T) {
+	set := NewSet("test", NewFSLoader(fstest.MapFS{
+		"button.html":         &fstest.MapFile{Data: []byte(`{% props variant %}<button>{{ slot }}</button>`)},
+		"button-primary.html": &fstest.MapFile{Data: []byte(`<button class="primary">{{ slot }}</button>`)},
+	}))
+	set.ComponentSet.RegisterComponent(&Component{
+		Name:         "button",
+		TemplateFile: "button.html",
+		ResolveTemplate: func(ctx *ComponentExecutionContext) (string, error) {
+			var props struct {
+				Variant string `pongo2:"variant"`
+			}
+			if err := ctx.Bind(&props); err != nil {
+				return "", err
+			}
+			switch props.Variant {
+			case "":
+				return "", nil
+			case "primary":
+				return "button-primary.html", nil
+			}
+			return "", fmt.Errorf("unknown variant '%s'", props.Variant)
+		},
+	})
+
+	out, err := set.RenderTemplateString(`{% component "button" %}OK{% endcomponent %}|{% component "button" withAttrs "variant"="primary" %}OK{% endcomponent %}`, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `<button>OK</button>|<button class="primary">OK</button>`, out)
+
+	_, err = set.RenderTemplateString(`{% component "button" withAttrs "variant"="danger" %}OK{% endcomponent %}`, nil)
+	assert.ErrorContains(t, err, "unknown variant 'danger'")
+}
+
+func TestComponentProvideAware(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "tabs",
+		TemplateString: `{% props active %}{% provide active %}<ul>{{ slot }}</ul>`,
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "tab",
+		TemplateString: `{% props name %}{% aware active, size="md" %}<li{% if name == active %} class="active"{% endif %} data-size="{{ size }}">{{ name }}</li>`,
+	})
+	set.ComponentSet.RegisterHeadlessComponent(&HeadlessComponent{
+		Name: "theme",
+		Setup: func(ctx *ComponentExecutionContext) error {
+			ctx.Provide("color", "dark")
+			return nil
+		},
+	})
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name:           "badge",
+		TemplateString: `<span class="{{ color }}">{{ slot }}</span>`,
+		Aware:          map[string]any{"color": "gray"},
+	})
+
+	t.Run("provided by props", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "tabs" withAttrs "active"="b" %}{% component "tab" withAttrs "name"="a" %}{% endcomponent %}{% component "tab" withAttrs "name"="b" "size"="lg" %}{% endcomponent %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<ul><li data-size="md">a</li><li class="active" data-size="lg">b</li></ul>`, out)
+	})
+
+	t.Run("provided by setup", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "badge" %}a{% endcomponent %}{% component "theme" %}{% component "badge" %}b{% endcomponent %}{% component "badge" withAttrs "color"="red" %}c{% endcomponent %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<span class="gray">a</span><span class="dark">b</span><span class="red">c</span>`, out)
+	})
+
+	t.Run("nearest ancestor", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% component "tabs" withAttrs "active"="a" %}{% component "tabs" withAttrs "active"="b" %}{% component "tab" withAttrs "name"="b" %}{% endcomponent %}{% endcomponent %}{% component "tab" withAttrs "name"="a" %}{% endcomponent %}{% endcomponent %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<ul><ul><li class="active" data-size="md">b</li></ul><li class="active" data-size="md">a</li></ul>`, out)
+	})
+}
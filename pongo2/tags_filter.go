@@ -4,15 +4,10 @@ import (
 	"bytes"
 )
 
-type nodeFilterCall struct {
-	name      string
-	paramExpr IEvaluator
-}
-
 type tagFilterNode struct {
 	position    *Token
 	bodyWrapper *NodeWrapper
-	filterChain []*nodeFilterCall
+	filterChain []*filterCall
 }
 
 func (node *tagFilterNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
@@ -26,18 +21,9 @@ func (node *tagFilterNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 	value := AsValue(temp.String())
 
 	for _, call := range node.filterChain {
-		var param *Value
-		if call.paramExpr != nil {
-			param, err = call.paramExpr.Evaluate(ctx)
-			if err != nil {
-				return err
-			}
-		} else {
-			param = AsValue(nil)
-		}
-		value, err = ApplyFilter(call.name, value, param)
+		value, err = call.Execute(value, ctx)
 		if err != nil {
-			return ctx.Error(err.Error(), node.position)
+			return err
 		}
 	}
 
@@ -58,22 +44,11 @@ func tagFilterParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *E
 	filterNode.bodyWrapper = wrapper
 
 	for arguments.Remaining() > 0 {
-		filterCall := &nodeFilterCall{}
-
-		nameToken := arguments.MatchType(TokenIdentifier)
-		if nameToken == nil {
-			return nil, arguments.Error("Expected a filter name (identifier).", nil)
-		}
-		filterCall.name = nameToken.Val
-
-		if arguments.MatchOne(TokenSymbol, ":") != nil {
-			// Filter parameter
-			// NOTICE: we can't use ParseExpression() here, because it would parse the next filter "|..." as well in the argument list
-			expr, err := arguments.parseVariableOrLiteral()
-			if err != nil {
-				return nil, err
-			}
-			filterCall.paramExpr = expr
+		// NOTICE: we can't use ParseExpression() for the filter parameter,
+		// because it would parse the next filter "|..." as well in the argument list
+		filterCall, err := arguments.parseFilter()
+		if err != nil {
+			return nil, err
 		}
 
 		filterNode.filterChain = append(filterNode.filterChain, filterCall)
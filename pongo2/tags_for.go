@@ -1,6 +1,7 @@
 package pongo2
 
 type tagForNode struct {
+	position        *Token
 	key             string
 	value           string // only for maps: for key, value in map
 	objectEvaluator IEvaluator
@@ -63,6 +64,16 @@ func (node *tagForNode) Execute(ctx *ExecutionContext, writer TemplateWriter) (f
 		loopInfo.Revcounter = count - idx        // TODO: Not sure about this, have to look it up
 		loopInfo.Revcounter0 = count - (idx + 1) // TODO: Not sure about this, have to look it up
 
+		// Stop the loop if the render is canceled, or exceeds the limit of the sandbox policy
+		if err := forCtx.checkCanceled(node.position); err != nil {
+			forError = err
+			return false
+		}
+		if err := forCtx.countLoopIteration(node.position); err != nil {
+			forError = err
+			return false
+		}
+
 		// Render elements with updated context
 		err := node.bodyWrapper.Execute(forCtx, writer)
 		if err != nil {
@@ -84,7 +95,9 @@ func (node *tagForNode) Execute(ctx *ExecutionContext, writer TemplateWriter) (f
 }
 
 func tagForParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
-	forNode := &tagForNode{}
+	forNode := &tagForNode{
+		position: start,
+	}
 
 	// Arguments parsing
 	var valueToken *Token
@@ -13,8 +13,8 @@ type tagImportNode struct {
 func (node *tagImportNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
 	for name, macro := range node.macros {
 		func(name string, macro *tagMacroNode) {
-			ctx.Private[name] = func(args ...*Value) (*Value, error) {
-				return macro.call(ctx, args...)
+			ctx.Private[name] = func(kwargs Kwargs, args ...*Value) (*Value, error) {
+				return macro.call(ctx, kwargs, args...)
 			}
 		}(name, macro)
 	}
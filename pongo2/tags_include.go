@@ -59,14 +59,14 @@ func (node *tagIncludeNode) Execute(ctx *ExecutionContext, writer TemplateWriter
 			}
 			return err2.(*Error)
 		}
-		err2 = includedTpl.ExecuteWriterWithEchoContext(includeCtx, writer, ctx.echoContext)
+		err2 = includedTpl.executeNested(ctx, includeCtx, writer)
 		if err2 != nil {
 			return err2.(*Error)
 		}
 		return nil
 	}
 	// Template is already parsed with static filename
-	err := node.tpl.ExecuteWriterWithEchoContext(includeCtx, writer, ctx.echoContext)
+	err := node.tpl.executeNested(ctx, includeCtx, writer)
 	if err != nil {
 		return err.(*Error)
 	}
@@ -18,7 +18,7 @@ type tagMacroNode struct {
 }
 
 func (node *tagMacroNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
-	ctx.Private[node.name] = func(args ...*Value) (*Value, error) {
+	ctx.Private[node.name] = func(kwargs Kwargs, args ...*Value) (*Value, error) {
 		ctx.macroDepth++
 		defer func() {
 			ctx.macroDepth--
@@ -27,14 +27,25 @@ func (node *tagMacroNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 		if ctx.macroDepth > maxMacroDepth {
 			return nil, ctx.Error(fmt.Sprintf("maximum recursive macro call depth reached (max is %v)", maxMacroDepth), node.position)
 		}
+		if sandbox := ctx.sandbox(); sandbox != nil {
+			if sandbox.MaxMacroDepth > 0 && ctx.macroDepth > sandbox.MaxMacroDepth {
+				return nil, ctx.OrigError(&SandboxError{
+					Violation: SandboxMacroDepth,
+					Message:   fmt.Sprintf("maximum recursive macro call depth reached (max is %d)", sandbox.MaxMacroDepth),
+				}, node.position)
+			}
+		}
+		if err := ctx.checkCanceled(node.position); err != nil {
+			return nil, err
+		}
 
-		return node.call(ctx, args...)
+		return node.call(ctx, kwargs, args...)
 	}
 
 	return nil
 }
 
-func (node *tagMacroNode) call(ctx *ExecutionContext, args ...*Value) (*Value, error) {
+func (node *tagMacroNode) call(ctx *ExecutionContext, kwargs Kwargs, args ...*Value) (*Value, error) {
 	argsCtx := make(Context)
 
 	for k, v := range node.args {
@@ -71,6 +82,14 @@ func (node *tagMacroNode) call(ctx *ExecutionContext, args ...*Value) (*Value, e
 		macroCtx.Private[node.argsOrder[idx]] = argValue.Interface()
 	}
 
+	// The arguments can also be passed by name, like: {{ input("email", type="email") }}
+	for name, argValue := range kwargs {
+		if _, ok := node.args[name]; !ok {
+			return AsSafeValue(""), ctx.Error(fmt.Sprintf("Macro '%s' has no argument '%s'.", node.name, name), nil).updateFromTokenIfNeeded(ctx.template, node.position)
+		}
+		macroCtx.Private[name] = argValue.Interface()
+	}
+
 	var b bytes.Buffer
 	err := node.wrapper.Execute(macroCtx, &b)
 	if err != nil {
@@ -1,16 +1,24 @@
 package pongo2
 
+import "fmt"
+
 // props tag
 // Usage:
 // {%- props key1=value1, key2=value2, key3=value3 -%}
 // {%- props key1=value1, key2, key3 -%}
+//
+// Props can be declared with a type and can be required:
+// {%- props title:string required, size:int=3, tags:slice -%}
+// Available types are: any, string, int, float, bool, slice, map and struct.
 
 type attributes struct {
 	keyValues map[string]IEvaluator
 }
 
 type tagsPropsNode struct {
+	position  *Token
 	keyValues map[string]IEvaluator
+	specs     map[string]*PropSpec
 }
 
 func (node *tagsPropsNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
@@ -23,8 +31,12 @@ func (node *tagsPropsNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 			if err != nil {
 				return err
 			}
+			checked, err2 := node.specs[key].check(val)
+			if err2 != nil {
+				return ctx.Error(err2.Error(), node.position)
+			}
 			// When setting a private context, the specified value will override the existing one.
-			ctx.Private[key] = val
+			ctx.Private[key] = checked
 		}
 	}
 
@@ -33,10 +45,12 @@ func (node *tagsPropsNode) Execute(ctx *ExecutionContext, writer TemplateWriter)
 
 func tagPropsParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
 	propsNode := &tagsPropsNode{
+		position:  start,
 		keyValues: make(map[string]IEvaluator),
+		specs:     make(map[string]*PropSpec),
 	}
 
-	propKeys := make([]string, 0)
+	specs := make([]*PropSpec, 0)
 
 	// Parse arguments
 	for arguments.Remaining() > 0 {
@@ -45,16 +59,43 @@ func tagPropsParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Er
 		if keyToken == nil {
 			return nil, arguments.Error("Expected a key (identifier).", nil)
 		}
-		key := keyToken.Val
+		spec := &PropSpec{Name: keyToken.Val}
+
+		// Check for `:` and retrieve the type if present
+		if arguments.Match(TokenSymbol, ":") != nil {
+			typeToken := arguments.MatchType(TokenIdentifier)
+			if typeToken == nil {
+				return nil, arguments.Error("Expected a prop type (identifier) after ':'.", nil)
+			}
+			typ, ok := parsePropType(typeToken.Val)
+			if !ok {
+				return nil, arguments.Error(fmt.Sprintf("Unknown prop type '%s'.", typeToken.Val), typeToken)
+			}
+			spec.Type = typ
+		}
+
+		// Check for the `required` flag
+		if arguments.Match(TokenIdentifier, "required") != nil {
+			spec.Required = true
+		}
 
 		// Check for `=` and retrieve the value if present
 		var value IEvaluator
 		if arguments.Match(TokenSymbol, "=") != nil {
+			if spec.Required {
+				return nil, arguments.Error(fmt.Sprintf("Required prop '%s' can not have a default value.", spec.Name), keyToken)
+			}
 			var err *Error
 			value, err = arguments.ParseExpression()
 			if err != nil {
 				return nil, err
 			}
+			// If the default value is a literal, we can check it at parse time.
+			if lit, ok := literalValue(value); ok {
+				if _, err := spec.check(lit); err != nil {
+					return nil, arguments.Error(err.Error(), keyToken)
+				}
+			}
 		} else {
 			// If `=` is not present, value is nil
 			value = nil
@@ -62,10 +103,11 @@ func tagPropsParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Er
 
 		// Add the key and value to the map
 		if value != nil {
-			propsNode.keyValues[key] = value
+			propsNode.keyValues[spec.Name] = value
 		}
-		// Add the key to the list of keys
-		propKeys = append(propKeys, key)
+		propsNode.specs[spec.Name] = spec
+		// Add the spec to the list of props
+		specs = append(specs, spec)
 
 		// If the next token is a comma, consume it
 		arguments.Match(TokenSymbol, ",") // No need to break, just consume
@@ -77,7 +119,7 @@ func tagPropsParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Er
 	}
 
 	// save defined props
-	doc.template.props = propKeys
+	doc.template.props = specs
 
 	return propsNode, nil
 }
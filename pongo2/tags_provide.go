@@ -0,0 +1,108 @@
+package pongo2
+
+// provide and aware tags
+// Usage:
+// {%- provide active, size -%}
+// {%- aware active, size="md" -%}
+//
+// The provide tag declares the data keys of a component that the descendant components can consume.
+// The aware tag declares the keys that a component consumes from the nearest ancestor component that provides them,
+// with the default values used when no ancestor provides them.
+// A value passed by the caller takes precedence over the provided value.
+
+type tagProvideNode struct{}
+
+func (node *tagProvideNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	// The provided values are collected by the component tag.
+	return nil
+}
+
+func tagProvideParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	keys := make([]string, 0)
+	for arguments.Remaining() > 0 {
+		keyToken := arguments.MatchType(TokenIdentifier)
+		if keyToken == nil {
+			return nil, arguments.Error("Expected a key (identifier).", nil)
+		}
+		keys = append(keys, keyToken.Val)
+
+		// If the next token is a comma, consume it
+		arguments.Match(TokenSymbol, ",")
+	}
+
+	// save provided keys
+	doc.template.provide = keys
+
+	return &tagProvideNode{}, nil
+}
+
+type tagAwareNode struct {
+	keyValues map[string]IEvaluator
+}
+
+func (node *tagAwareNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	for key, value := range node.keyValues {
+		// If the key is not set in the public context,
+		// neither the caller nor the ancestor components passed the value.
+		// In this case, we set the default value.
+		if ctx.Public[key] == nil {
+			val, err := value.Evaluate(ctx)
+			if err != nil {
+				return err
+			}
+			ctx.Private[key] = val
+		}
+	}
+	return nil
+}
+
+func tagAwareParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	awareNode := &tagAwareNode{
+		keyValues: make(map[string]IEvaluator),
+	}
+
+	keys := make([]string, 0)
+	for arguments.Remaining() > 0 {
+		keyToken := arguments.MatchType(TokenIdentifier)
+		if keyToken == nil {
+			return nil, arguments.Error("Expected a key (identifier).", nil)
+		}
+		keys = append(keys, keyToken.Val)
+
+		// Check for `=` and retrieve the default value if present
+		if arguments.Match(TokenSymbol, "=") != nil {
+			value, err := arguments.ParseExpression()
+			if err != nil {
+				return nil, err
+			}
+			awareNode.keyValues[keyToken.Val] = value
+		}
+
+		// If the next token is a comma, consume it
+		arguments.Match(TokenSymbol, ",")
+	}
+
+	// save consumed keys
+	doc.template.aware = keys
+
+	return awareNode, nil
+}
+
+// componentProvide returns the keys that the component provides to the descendant components.
+func componentProvide(comp *component, tpl *Template) []string {
+	return append(append([]string{}, comp.Provide...), tpl.provide...)
+}
+
+// componentAware returns the keys that the component consumes from the ancestor components.
+func componentAware(comp *component, tpl *Template) []string {
+	keys := append([]string{}, tpl.aware...)
+	for key := range comp.Aware {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func init() {
+	RegisterTag("provide", tagProvideParser)
+	RegisterTag("aware", tagAwareParser)
+}
@@ -15,7 +15,7 @@ func (node *tagSSINode) Execute(ctx *ExecutionContext, writer TemplateWriter) *E
 		includeCtx.Update(ctx.Public)
 		includeCtx.Update(ctx.Private)
 
-		err := node.template.executeWithEchoContext(includeCtx, writer, ctx.echoContext)
+		err := node.template.executeNested(ctx, includeCtx, writer)
 		if err != nil {
 			return err.(*Error)
 		}
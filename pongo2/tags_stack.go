@@ -0,0 +1,138 @@
+package pongo2
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// push, pushonce and stack tags
+// Usage:
+// {% push "scripts" %}<script src="/app.js"></script>{% endpush %}
+// {% pushonce "scripts" %}<script src="/datepicker.js"></script>{% endpushonce %}
+// {% pushonce "scripts" "datepicker" %}<script src="/datepicker.js"></script>{% endpushonce %}
+// {% stack "scripts" %}
+//
+// The content pushed in the whole render, including layouts, included templates and components,
+// is output where the stack tag is declared, even if the stack tag is earlier than the push tags.
+
+type tagPushNode struct {
+	position *Token
+	name     string
+	once     bool
+	key      IEvaluator
+	wrapper  *NodeWrapper
+}
+
+func (node *tagPushNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	if ctx.render == nil {
+		return ctx.Error("push tag can not be used outside of a template render.", node.position)
+	}
+
+	var key string
+	if node.once {
+		if node.key != nil {
+			val, err := node.key.Evaluate(ctx)
+			if err != nil {
+				return err
+			}
+			key = val.String()
+		} else {
+			// Without a key, the content is pushed once per tag.
+			key = fmt.Sprintf("%p", node)
+		}
+		if ctx.render.isPushed(node.name, key) && !ctx.render.recording() {
+			// Already pushed. Skip rendering the content unless it is recorded to be replayed in another render.
+			return nil
+		}
+	}
+
+	var b bytes.Buffer
+	if err := node.wrapper.Execute(ctx, &b); err != nil {
+		return err
+	}
+
+	if node.once {
+		ctx.render.pushOnce(node.name, key, b.String())
+	} else {
+		ctx.render.push(node.name, b.String())
+	}
+	return nil
+}
+
+type tagStackNode struct {
+	position *Token
+	name     string
+}
+
+func (node *tagStackNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	if ctx.render == nil {
+		return ctx.Error("stack tag can not be used outside of a template render.", node.position)
+	}
+	writer.WriteString(ctx.render.stackPlaceholder(node.name))
+	return nil
+}
+
+func newTagPushParser(once bool) TagParser {
+	tagName := "push"
+	if once {
+		tagName = "pushonce"
+	}
+
+	return func(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+		pushNode := &tagPushNode{
+			position: start,
+			once:     once,
+		}
+
+		nameToken := arguments.MatchType(TokenString)
+		if nameToken == nil {
+			return nil, arguments.Error(fmt.Sprintf("%s tag needs a stack name as first argument.", tagName), nil)
+		}
+		pushNode.name = nameToken.Val
+
+		if once && arguments.Remaining() > 0 {
+			key, err := arguments.ParseExpression()
+			if err != nil {
+				return nil, err
+			}
+			pushNode.key = key
+		}
+
+		if arguments.Remaining() > 0 {
+			return nil, arguments.Error(fmt.Sprintf("Malformed %s-tag arguments.", tagName), nil)
+		}
+
+		wrapper, endtagargs, err := doc.WrapUntilTag("end" + tagName)
+		if err != nil {
+			return nil, err
+		}
+		if endtagargs.Count() > 0 {
+			return nil, endtagargs.Error("Arguments not allowed here.", nil)
+		}
+		pushNode.wrapper = wrapper
+
+		return pushNode, nil
+	}
+}
+
+func tagStackParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	stackNode := &tagStackNode{position: start}
+
+	nameToken := arguments.MatchType(TokenString)
+	if nameToken == nil {
+		return nil, arguments.Error("stack tag needs a stack name as first argument.", nil)
+	}
+	stackNode.name = nameToken.Val
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed stack-tag arguments.", nil)
+	}
+
+	return stackNode, nil
+}
+
+func init() {
+	RegisterTag("push", newTagPushParser(false))
+	RegisterTag("pushonce", newTagPushParser(true))
+	RegisterTag("stack", tagStackParser)
+}
@@ -0,0 +1,73 @@
+package pongo2
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPushAndStack(t *testing.T) {
+	set := NewSet("test", NewFSLoader(fstest.MapFS{
+		"layout.html":  {Data: []byte(`<head>{% stack "styles" %}</head><body>{% block content %}{% endblock %}{% stack "scripts" %}</body>`)},
+		"page.html":    {Data: []byte(`{% extends "layout.html" %}{% block content %}{% include "partial.html" %}{% for i in items %}{% component "datepicker" %}{% endcomponent %}{% endfor %}{% endblock %}`)},
+		"partial.html": {Data: []byte(`<p>partial</p>{% push "styles" %}<link href="/partial.css">{% endpush %}`)},
+	}))
+	set.ComponentSet.RegisterInlineComponent(&InlineComponent{
+		Name: "datepicker",
+		TemplateString: `<input type="date">` +
+			`{% pushonce "scripts" %}<script src="/datepicker.js"></script>{% endpushonce %}` +
+			`{% pushonce "scripts" "lib" %}<script src="/lib.js"></script>{% endpushonce %}` +
+			`{% push "scripts" %}<script>init()</script>{% endpush %}`,
+	})
+
+	t.Run("collect pushes across the render", func(t *testing.T) {
+		out, err := set.RenderTemplateFile("page.html", Context{"items": []int{1, 2}})
+		assert.NoError(t, err)
+		assert.Equal(t, `<head><link href="/partial.css"></head><body><p>partial</p><input type="date"><input type="date">`+
+			`<script src="/datepicker.js"></script><script src="/lib.js"></script><script>init()</script><script>init()</script></body>`, out)
+	})
+
+	t.Run("output is buffered only after a stack tag", func(t *testing.T) {
+		fail := func() (string, error) { return "", errors.New("failed") }
+
+		tpl, err := set.FromString(`<p>a</p>{{ fail() }}`)
+		assert.NoError(t, err)
+		var b strings.Builder
+		assert.Error(t, tpl.ExecuteWriterUnbuffered(Context{"fail": fail}, &b))
+		assert.Equal(t, "<p>a</p>", b.String())
+
+		tpl, err = set.FromString(`<p>a</p>{% stack "scripts" %}<p>b</p>{{ fail() }}`)
+		assert.NoError(t, err)
+		b.Reset()
+		assert.Error(t, tpl.ExecuteWriterUnbuffered(Context{"fail": fail}, &b))
+		assert.Equal(t, "<p>a</p>", b.String())
+
+		tpl, err = set.FromString(`<p>a</p>{% stack "scripts" %}<p>b</p>{% push "scripts" %}<script></script>{% endpush %}`)
+		assert.NoError(t, err)
+		b.Reset()
+		assert.NoError(t, tpl.ExecuteWriterUnbuffered(nil, &b))
+		assert.Equal(t, "<p>a</p><script></script><p>b</p>", b.String())
+	})
+
+	t.Run("pushonce key", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`{% stack "js" %}|{% pushonce "js" key %}a{% endpushonce %}{% pushonce "js" key %}b{% endpushonce %}{% pushonce "other" key %}c{% endpushonce %}`, Context{"key": "k"})
+		assert.NoError(t, err)
+		assert.Equal(t, "a|", out)
+	})
+
+	t.Run("empty and undeclared stacks", func(t *testing.T) {
+		out, err := set.RenderTemplateString(`[{% stack "empty" %}]{% push "unused" %}x{% endpush %}`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "[]", out)
+	})
+
+	t.Run("execute blocks", func(t *testing.T) {
+		tpl, err := set.FromString(`{% block a %}{% stack "s" %}{% push "s" %}x{% endpush %}{% endblock %}`)
+		assert.NoError(t, err)
+		blocks, err := tpl.ExecuteBlocks(nil, []string{"a"})
+		assert.NoError(t, err)
+		assert.Equal(t, "x", blocks["a"])
+	})
+}
@@ -0,0 +1,277 @@
+package pongo2
+
+import (
+	"strings"
+)
+
+// trans tag
+// Usage:
+// {% trans "Hello" %}
+// {% trans message %}
+// {% trans "Hello" as greeting %}
+//
+// The message is translated by the Catalog of the template set in the locale of the user.
+// The translation of a string literal is output as it is, and the one of a variable is escaped.
+
+type tagTransNode struct {
+	message IEvaluator
+	// literal is true if the message is a string literal
+	literal bool
+	asName  string
+}
+
+func (node *tagTransNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	message, err := node.message.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	translation := AsValue(ctx.translate(message.String()))
+	if node.literal {
+		translation = AsSafeValue(translation.String())
+	}
+
+	if node.asName != "" {
+		ctx.Private[node.asName] = translation
+		return nil
+	}
+	writer.WriteString(ctx.escapeValue(translation))
+	return nil
+}
+
+func tagTransParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	transNode := &tagTransNode{
+		literal: arguments.PeekType(TokenString) != nil,
+	}
+
+	message, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	transNode.message = message
+
+	if arguments.Match(TokenKeyword, "as") != nil {
+		nameToken := arguments.MatchType(TokenIdentifier)
+		if nameToken == nil {
+			return nil, arguments.Error("Expected an identifier after 'as'.", nil)
+		}
+		transNode.asName = nameToken.Val
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed trans-tag arguments.", nil)
+	}
+
+	return transNode, nil
+}
+
+// blocktrans tag
+// Usage:
+// {% blocktrans %}Hello, {{ name }}.{% endblocktrans %}
+// {% blocktrans with name=user.Name %}Hello, {{ name }}.{% endblocktrans %}
+// {% blocktrans count=items|length %}{{ count }} item{% plural %}{{ count }} items{% endblocktrans %}
+// {% blocktrans count n=items|length trimmed %}
+//   {{ n }} item
+// {% plural %}
+//   {{ n }} items
+// {% endblocktrans %}
+//
+// The body can have only text and simple variables like {{ name }}.
+// The message id is the body with the variables as placeholders like "%(name)s", and "%" as "%%".
+// With trimmed, the whitespaces at the beginning and the end of the lines are removed and the lines are joined by a space.
+// The translation is output as it is, and the values of the variables are escaped.
+
+type tagBlocktransNode struct {
+	position  *Token
+	singular  string
+	plural    string
+	countName string
+	count     IEvaluator
+	withNames []string
+	with      map[string]IEvaluator
+}
+
+func (node *tagBlocktransNode) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	values := make(map[string]*Value, len(node.with)+1)
+	for _, name := range node.withNames {
+		val, err := node.with[name].Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		values[name] = val
+	}
+
+	var message string
+	if node.count != nil {
+		count, err := node.count.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		values[node.countName] = count
+		message = ctx.translatePlural(node.singular, node.plural, count.Integer())
+	} else {
+		message = ctx.translate(node.singular)
+	}
+
+	var lookupErr *Error
+	out := interpolate(message, func(name string) (string, bool) {
+		val, ok := values[name]
+		if !ok {
+			// The other variables are resolved from the context.
+			var err *Error
+			val, err = (&variableResolver{
+				locationToken: node.position,
+				parts:         []*variablePart{{typ: varTypeIdent, s: name}},
+			}).Evaluate(ctx)
+			if err != nil {
+				if lookupErr == nil {
+					lookupErr = err
+				}
+				return "", false
+			}
+		}
+		return ctx.escapeValue(val), true
+	})
+	if lookupErr != nil {
+		return lookupErr
+	}
+
+	writer.WriteString(out)
+	return nil
+}
+
+func tagBlocktransParser(doc *Parser, start *Token, arguments *Parser) (INodeTag, *Error) {
+	blocktransNode := &tagBlocktransNode{
+		position: start,
+		with:     make(map[string]IEvaluator),
+	}
+
+	trimmed := false
+	for arguments.Remaining() > 0 {
+		switch {
+		case arguments.Match(TokenIdentifier, "count") != nil:
+			if blocktransNode.count != nil {
+				return nil, arguments.Error("count is already given.", nil)
+			}
+			// count=expr or count name=expr
+			blocktransNode.countName = "count"
+			if nameToken := arguments.MatchType(TokenIdentifier); nameToken != nil {
+				blocktransNode.countName = nameToken.Val
+			}
+			if arguments.Match(TokenSymbol, "=") == nil {
+				return nil, arguments.Error("Expected '=' after the count name.", nil)
+			}
+			count, err := arguments.ParseExpression()
+			if err != nil {
+				return nil, err
+			}
+			blocktransNode.count = count
+		case arguments.Match(TokenIdentifier, "with") != nil:
+			for arguments.PeekType(TokenIdentifier) != nil && arguments.PeekN(1, TokenSymbol, "=") != nil {
+				nameToken := arguments.MatchType(TokenIdentifier)
+				arguments.Consume() // consume '='
+				value, err := arguments.ParseExpression()
+				if err != nil {
+					return nil, err
+				}
+				if _, exists := blocktransNode.with[nameToken.Val]; !exists {
+					blocktransNode.withNames = append(blocktransNode.withNames, nameToken.Val)
+				}
+				blocktransNode.with[nameToken.Val] = value
+				arguments.Match(TokenSymbol, ",")
+			}
+		case arguments.Match(TokenIdentifier, "trimmed") != nil:
+			trimmed = true
+		default:
+			return nil, arguments.Error("Malformed blocktrans-tag arguments.", nil)
+		}
+	}
+
+	singular, plural, err := parseBlocktransBody(doc, blocktransNode.count != nil, trimmed)
+	if err != nil {
+		return nil, err
+	}
+	blocktransNode.singular = singular
+	blocktransNode.plural = plural
+
+	return blocktransNode, nil
+}
+
+// parseBlocktransBody parses the body of the blocktrans tag until the endblocktrans tag,
+// and returns the singular and plural message ids.
+func parseBlocktransBody(p *Parser, hasCount bool, trimmed bool) (string, string, *Error) {
+	var singular, plural strings.Builder
+	message := &singular
+	hasPlural := false
+
+	for p.Remaining() > 0 {
+		t := p.Current()
+		switch {
+		case t.Typ == TokenHTML:
+			text := t.Val
+			if left := p.PeekTypeN(-1, TokenSymbol); left != nil && left.TrimWhitespaces {
+				text = strings.TrimLeft(text, tokenSpaceChars)
+			}
+			if right := p.PeekTypeN(1, TokenSymbol); right != nil && right.TrimWhitespaces {
+				text = strings.TrimRight(text, tokenSpaceChars)
+			}
+			message.WriteString(strings.ReplaceAll(text, "%", "%%"))
+			p.Consume()
+		case p.Match(TokenSymbol, "{{") != nil:
+			nameToken := p.MatchType(TokenIdentifier)
+			if nameToken == nil || p.Match(TokenSymbol, "}}") == nil {
+				return "", "", p.Error("Only simple variables like {{ name }} are allowed in blocktrans.", t)
+			}
+			message.WriteString("%(" + nameToken.Val + ")s")
+		case p.Peek(TokenSymbol, "{%") != nil:
+			tagToken := p.PeekTypeN(1, TokenIdentifier)
+			if tagToken == nil || (tagToken.Val != "plural" && tagToken.Val != "endblocktrans") {
+				return "", "", p.Error("Tags other than plural are not allowed in blocktrans.", t)
+			}
+			p.ConsumeN(2)
+			if p.Match(TokenSymbol, "%}") == nil {
+				return "", "", p.Error("Arguments not allowed here.", nil)
+			}
+
+			if tagToken.Val == "plural" {
+				if !hasCount {
+					return "", "", p.Error("The plural tag needs count in blocktrans.", tagToken)
+				}
+				if hasPlural {
+					return "", "", p.Error("The plural tag is already given.", tagToken)
+				}
+				hasPlural = true
+				message = &plural
+				continue
+			}
+
+			if hasCount && !hasPlural {
+				return "", "", p.Error("blocktrans with count needs the plural tag.", tagToken)
+			}
+			if trimmed {
+				return trimMessage(singular.String()), trimMessage(plural.String()), nil
+			}
+			return singular.String(), plural.String(), nil
+		default:
+			return "", "", p.Error("Unexpected token in blocktrans.", t)
+		}
+	}
+
+	return "", "", p.Error("Unexpected EOF, expected tag endblocktrans.", p.lastToken)
+}
+
+// trimMessage removes the whitespaces at the beginning and the end of the lines and joins the lines by a space.
+func trimMessage(s string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func init() {
+	RegisterTag("trans", tagTransParser)
+	RegisterTag("blocktrans", tagBlocktransParser)
+}
@@ -48,7 +48,10 @@ type Template struct {
 	exportedMacros map[string]*tagMacroNode
 
 	// Defined props of a component
-	props []string
+	props []*PropSpec
+	// Provided and consumed keys of a component
+	provide []string
+	aware   []string
 
 	// fragments
 	fragments map[string]*NodeWrapper
@@ -78,7 +81,7 @@ func newTemplate(set *TemplateSet, name string, isTplString bool, tpl []byte) (*
 		size:           len(strTpl),
 		blocks:         make(map[string]*NodeWrapper),
 		exportedMacros: make(map[string]*tagMacroNode),
-		props:          make([]string, 0),
+		props:          make([]*PropSpec, 0),
 		fragments:      make(map[string]*NodeWrapper),
 		Options:        newOptions(),
 	}
@@ -188,13 +191,17 @@ func (tpl *Template) executeWithEchoContext(context Context, writer TemplateWrit
 	if err != nil {
 		return err
 	}
+	ctx.render = newRenderState()
+	defer ctx.render.begin(tpl.set, requestContext(eCtx))()
 
 	// Run the selected document
-	if err := parent.root.Execute(ctx, writer); err != nil {
+	w := newStackWriter(writer, ctx.render)
+	if err := parent.root.Execute(ctx, w); err != nil {
 		return err
 	}
 
-	return nil
+	// Output the rest of the document with the pushed stack content
+	return w.flush()
 }
 
 func (tpl *Template) executeFragmentWithEchoContext(context Context, fragmentName string, writer TemplateWriter, eCtx echo.Context) error {
@@ -202,6 +209,8 @@ func (tpl *Template) executeFragmentWithEchoContext(context Context, fragmentNam
 	if err != nil {
 		return err
 	}
+	ctx.render = newRenderState()
+	defer ctx.render.begin(tpl.set, requestContext(eCtx))()
 
 	fragment, ok := parent.fragments[fragmentName]
 	if !ok {
@@ -209,7 +218,35 @@ func (tpl *Template) executeFragmentWithEchoContext(context Context, fragmentNam
 	}
 
 	// Run the selected fragment
-	if err := fragment.Execute(ctx, writer); err != nil {
+	w := newStackWriter(writer, ctx.render)
+	if err := fragment.Execute(ctx, w); err != nil {
+		return err
+	}
+
+	// Output the rest of the fragment with the pushed stack content
+	return w.flush()
+}
+
+// executeNested executes the template within the render of the parent context,
+// like an included template or a component template.
+// The render state, such as the content pushed to the stacks, is shared with the parent.
+func (tpl *Template) executeNested(parentCtx *ExecutionContext, context Context, writer TemplateWriter) error {
+	parent, ctx, err := tpl.newContextForExecutionWithEchoContext(context, parentCtx.echoContext)
+	if err != nil {
+		return err
+	}
+	ctx.render = parentCtx.render
+	ctx.provided = parentCtx.provided
+	if err := ctx.checkCanceled(nil); err != nil {
+		return err
+	}
+
+	// Nothing is written on error
+	buffer := bytes.NewBuffer(make([]byte, 0, int(float64(tpl.size)*1.3)))
+	if err := parent.root.Execute(ctx, buffer); err != nil {
+		return err
+	}
+	if _, err := buffer.WriteTo(writer); err != nil {
 		return err
 	}
 
@@ -322,32 +359,8 @@ func (tpl *Template) ExecuteBlocks(context Context, blocks []string) (map[string
 	}
 
 	for _, t := range parents {
-		var buffer *bytes.Buffer
-		var ctx *ExecutionContext
-		var err error
-		for _, blockName := range blocks {
-			if _, ok := result[blockName]; ok {
-				continue
-			}
-			if blockWrapper, ok := t.blocks[blockName]; ok {
-				// assign the buffer if we haven't done so
-				if buffer == nil {
-					buffer = bytes.NewBuffer(make([]byte, 0, int(float64(t.size)*1.3)))
-				}
-				// assign the context if we haven't done so
-				if ctx == nil {
-					_, ctx, err = t.newContextForExecution(context)
-					if err != nil {
-						return nil, err
-					}
-				}
-				bErr := blockWrapper.Execute(ctx, buffer)
-				if bErr != nil {
-					return nil, bErr
-				}
-				result[blockName] = buffer.String()
-				buffer.Reset()
-			}
+		if err := t.executeBlocks(context, blocks, result); err != nil {
+			return nil, err
 		}
 		// We have found all blocks
 		if len(blocks) == len(result) {
@@ -357,3 +370,42 @@ func (tpl *Template) ExecuteBlocks(context Context, blocks []string) (map[string
 
 	return result, nil
 }
+
+// executeBlocks executes the blocks of the template that are not in the result yet, and adds them to the result.
+func (t *Template) executeBlocks(context Context, blocks []string, result map[string]string) error {
+	var buffer *bytes.Buffer
+	var ctx *ExecutionContext
+	for _, blockName := range blocks {
+		if _, ok := result[blockName]; ok {
+			continue
+		}
+		blockWrapper, ok := t.blocks[blockName]
+		if !ok {
+			continue
+		}
+		// assign the buffer if we haven't done so
+		if buffer == nil {
+			buffer = bytes.NewBuffer(make([]byte, 0, int(float64(t.size)*1.3)))
+		}
+		// assign the context if we haven't done so
+		if ctx == nil {
+			var err error
+			_, ctx, err = t.newContextForExecution(context)
+			if err != nil {
+				return err
+			}
+			ctx.render = newRenderState()
+			defer ctx.render.begin(t.set, nil)()
+		}
+		w := newStackWriter(buffer, ctx.render)
+		if err := blockWrapper.Execute(ctx, w); err != nil {
+			return err
+		}
+		if err := w.flush(); err != nil {
+			return err
+		}
+		result[blockName] = buffer.String()
+		buffer.Reset()
+	}
+	return nil
+}
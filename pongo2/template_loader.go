@@ -9,7 +9,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
 // FSLoader supports the fs.FS interface for loading templates
@@ -304,3 +306,70 @@ func NewPreProcessLoader(loader TemplateLoader, preProcessors ...PreProcessor) *
 		preProcessors: preProcessors,
 	}
 }
+
+// NamespaceSeparator separates a namespace and a template path, like "ui::components/button.html".
+const NamespaceSeparator = "::"
+
+// NamespaceLoader is a TemplateLoader that loads namespaced templates like "ui::components/button.html"
+// from the file system registered for the namespace.
+// The other templates are loaded by the underlying loader.
+//
+// An application can override a namespaced template by placing a file at "<overrideDir>/<namespace>/<path>"
+// that is loaded by the underlying loader.
+type NamespaceLoader struct {
+	loader      TemplateLoader
+	overrideDir string
+	namespaces  map[string]fs.FS
+}
+
+func NewNamespaceLoader(loader TemplateLoader, overrideDir string) *NamespaceLoader {
+	return &NamespaceLoader{
+		loader:      loader,
+		overrideDir: overrideDir,
+		namespaces:  make(map[string]fs.FS),
+	}
+}
+
+// AddNamespace registers the file system for the namespace.
+func (l *NamespaceLoader) AddNamespace(namespace string, fsys fs.FS) {
+	l.namespaces[namespace] = fsys
+}
+
+func (l *NamespaceLoader) Abs(base, name string) string {
+	if namespace, p, ok := splitNamespace(name); ok {
+		return namespace + NamespaceSeparator + path.Clean(p)
+	}
+	if namespace, p, ok := splitNamespace(base); ok {
+		// A relative path in a namespaced template is resolved in the same namespace.
+		return namespace + NamespaceSeparator + path.Join(path.Dir(p), filepath.ToSlash(name))
+	}
+	return l.loader.Abs(base, name)
+}
+
+func (l *NamespaceLoader) Get(name string) (io.Reader, error) {
+	namespace, p, ok := splitNamespace(name)
+	if !ok {
+		return l.loader.Get(name)
+	}
+
+	fsys, ok := l.namespaces[namespace]
+	if !ok {
+		return nil, fmt.Errorf("unknown template namespace '%s'", namespace)
+	}
+
+	// The application can override the template.
+	if l.overrideDir != "" {
+		if r, err := l.loader.Get(l.loader.Abs("", path.Join(l.overrideDir, namespace, p))); err == nil {
+			return r, nil
+		}
+	}
+	return fsys.Open(p)
+}
+
+func splitNamespace(name string) (namespace string, p string, ok bool) {
+	namespace, p, ok = strings.Cut(name, NamespaceSeparator)
+	if !ok || namespace == "" {
+		return "", name, false
+	}
+	return namespace, p, true
+}
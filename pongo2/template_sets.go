@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"sync"
+	"time"
 )
 
 // TemplateLoader allows to implement a virtual file system.
@@ -27,7 +28,8 @@ type TemplateSet struct {
 	name    string
 	loaders []TemplateLoader
 
-	// Globals will be provided to all templates created within this template set
+	// Globals will be provided to all templates created within this template set.
+	// It has the _ function to translate messages, which the context of a render can override.
 	Globals Context
 
 	// If debug is true (default false), ExecutionContext.Logf() will work and output
@@ -36,20 +38,57 @@ type TemplateSet struct {
 	// variable during program execution (and template compilation/execution).
 	Debug bool
 
+	// If StrictUndefined is true (default false), resolving an undefined variable, a missing field or map key,
+	// or an index out of range is an execution error instead of an empty value.
+	// Use the default filter, the ?? operator or the "is defined" test to allow an undefined value.
+	StrictUndefined bool
+
+	// TimezoneKey is the context key of the time zone of the user, like "timezone".
+	// Its value is a name of the IANA Time Zone database or a *time.Location.
+	// The filters with arguments, like date, receive it as the tz keyword argument unless tz is passed.
+	TimezoneKey string
+
+	// Location is the time zone passed to the filters with arguments as the tz keyword argument
+	// if neither tz nor the value of TimezoneKey is given. If it is nil, times are formatted in their own location.
+	Location *time.Location
+
+	// Catalog is the store of the translated messages used by the trans and blocktrans tags and the _ function.
+	// If it is nil, the messages are not translated.
+	Catalog Catalog
+
+	// LocaleKey is the context key of the locale of the user, like "locale".
+	// The filters with arguments, like number, receive it as the locale keyword argument unless locale is passed.
+	LocaleKey string
+
+	// RenderTimeout is the maximum duration of a render. A render that exceeds it is aborted with *RenderCanceledError.
+	// A render with an echo context is also aborted when the context of the request is canceled.
+	// If it is zero (default), there is no timeout.
+	RenderTimeout time.Duration
+
+	// Sandbox restricts what the templates can do at execution, for the templates written by untrusted users.
+	// If it is nil (default), the templates are not restricted.
+	Sandbox *SandboxPolicy
+
 	// Options allow you to change the behavior of template-engine.
 	// You can change the options before calling the Execute method.
 	Options *Options
 
 	// Sandbox features
 	// - Disallow access to specific tags and/or filters (using BanTag() and BanFilter())
+	// - Restrict method calls, types, loops, output size, recursion and execution time (using Sandbox)
 	//
 	// For efficiency reasons you can ban tags/filters only *before* you have
 	// added your first template to the set (restrictions are statically checked).
 	// After you added one, it's not possible anymore (for your personal security).
-	tags          map[string]*tag
-	filters       map[string]FilterFunction
-	bannedTags    map[string]bool
-	bannedFilters map[string]bool
+	tags    map[string]*tag
+	filters map[string]FilterFunction
+	// filtersWithArgs are the filters that accept multiple positional and keyword arguments
+	filtersWithArgs map[string]FilterFunctionWithArgs
+	// filterContextKwargs are the keyword arguments that the builtin filters take from the context
+	filterContextKwargs map[string][]contextKwarg
+	tests               map[string]TestFunction
+	bannedTags          map[string]bool
+	bannedFilters       map[string]bool
 
 	// Template cache (for FromCache())
 	templateCache      map[string]*Template
@@ -61,6 +100,11 @@ type TemplateSet struct {
 
 	// components
 	ComponentSet *componentSet
+
+	// Cache is a store for the rendered output of the cached components and the cache tag.
+	// The default is an in-memory cache of DefaultMemoryCacheSize entries.
+	// If it is nil, the output is not cached.
+	Cache Cache
 }
 
 // newSet only be used to create sets without default tags and filters
@@ -69,19 +113,24 @@ func newSet(name string, loaders ...TemplateLoader) *TemplateSet {
 		panic(fmt.Errorf("at least one template loader must be specified"))
 	}
 
-	return &TemplateSet{
-		name:              name,
-		loaders:           loaders,
-		Globals:           make(Context),
-		tags:              make(map[string]*tag),
-		filters:           make(map[string]FilterFunction),
-		bannedTags:        make(map[string]bool),
-		bannedFilters:     make(map[string]bool),
-		templateCache:     make(map[string]*Template),
-		Options:           newOptions(),
-		SharedContextKeys: []string{},
-		ComponentSet:      newComponentSet(),
+	set := &TemplateSet{
+		name:                name,
+		loaders:             loaders,
+		Globals:             Context{"_": translateFunc},
+		tags:                make(map[string]*tag),
+		filters:             make(map[string]FilterFunction),
+		filtersWithArgs:     make(map[string]FilterFunctionWithArgs),
+		filterContextKwargs: make(map[string][]contextKwarg),
+		tests:               make(map[string]TestFunction),
+		bannedTags:          make(map[string]bool),
+		bannedFilters:       make(map[string]bool),
+		templateCache:       make(map[string]*Template),
+		Options:             newOptions(),
+		SharedContextKeys:   []string{},
+		Cache:               NewMemoryCache(DefaultMemoryCacheSize),
 	}
+	set.ComponentSet = newComponentSet(set)
+	return set
 }
 
 // NewSet can be used to create sets with different kind of templates
@@ -96,6 +145,15 @@ func NewSet(name string, loaders ...TemplateLoader) *TemplateSet {
 	for name, filter := range DefaultSet.filters {
 		set.filters[name] = filter
 	}
+	for name, filter := range DefaultSet.filtersWithArgs {
+		set.filtersWithArgs[name] = filter
+	}
+	for name, kwargs := range DefaultSet.filterContextKwargs {
+		set.filterContextKwargs[name] = kwargs
+	}
+	for name, test := range DefaultSet.tests {
+		set.tests[name] = test
+	}
 	return set
 }
 
@@ -138,11 +196,10 @@ func (set *TemplateSet) BanTag(name string) error {
 // BanFilter bans a specific filter for this template set. See more in the documentation for TemplateSet.
 // This method must be called before you've added your first template to the set.
 func (set *TemplateSet) BanFilter(name string) error {
-	_, has := set.filters[name]
-	if !has {
+	if !set.FilterExists(name) {
 		return fmt.Errorf("filter '%s' not found", name)
 	}
-	_, has = set.bannedFilters[name]
+	_, has := set.bannedFilters[name]
 	if has {
 		return fmt.Errorf("filter '%s' is already banned", name)
 	}
@@ -307,18 +364,23 @@ var (
 	DefaultSet = newSet("default", DefaultLoader)
 
 	// Methods on the default set
-	FromString           = DefaultSet.FromString
-	FromBytes            = DefaultSet.FromBytes
-	FromFile             = DefaultSet.FromFile
-	FromCache            = DefaultSet.FromCache
-	RenderTemplateString = DefaultSet.RenderTemplateString
-	RenderTemplateFile   = DefaultSet.RenderTemplateFile
-	ReplaceTag           = DefaultSet.ReplaceTag
-	RegisterTag          = DefaultSet.RegisterTag
-	ReplaceFilter        = DefaultSet.ReplaceFilter
-	RegisterFilter       = DefaultSet.RegisterFilter
-	ApplyFilter          = DefaultSet.ApplyFilter
-	MustApplyFilter      = DefaultSet.MustApplyFilter
+	FromString             = DefaultSet.FromString
+	FromBytes              = DefaultSet.FromBytes
+	FromFile               = DefaultSet.FromFile
+	FromCache              = DefaultSet.FromCache
+	RenderTemplateString   = DefaultSet.RenderTemplateString
+	RenderTemplateFile     = DefaultSet.RenderTemplateFile
+	ReplaceTag             = DefaultSet.ReplaceTag
+	RegisterTag            = DefaultSet.RegisterTag
+	ReplaceFilter          = DefaultSet.ReplaceFilter
+	RegisterFilter         = DefaultSet.RegisterFilter
+	ApplyFilter            = DefaultSet.ApplyFilter
+	RegisterFilterWithArgs = DefaultSet.RegisterFilterWithArgs
+	ReplaceFilterWithArgs  = DefaultSet.ReplaceFilterWithArgs
+	ApplyFilterWithArgs    = DefaultSet.ApplyFilterWithArgs
+	MustApplyFilter        = DefaultSet.MustApplyFilter
+	ReplaceTest            = DefaultSet.ReplaceTest
+	RegisterTest           = DefaultSet.RegisterTest
 
 	// Globals for the default set
 	Globals = DefaultSet.Globals
@@ -71,4 +71,25 @@ issue #64 (uint comparison with int const)
 string concatenation
 {{ "a" + "b" }}
 {{ 1 + "a" }}
-{{ "a" + "1" }}
\ No newline at end of file
+{{ "a" + "1" }}
+
+map literals
+{{ {"size": "sm", "count": 2}|length }}
+{{ {}|length }}
+{% with opts={"size": "sm", "count": simple.number} %}{{ opts.size }}-{{ opts.count }}{% endwith %}
+{% with opts={"nested": {"a": 1} } %}{{ opts.nested.a }}{% endwith %}
+
+conditional expressions
+{{ "yes" if simple.number == 42 else "no" }}
+{{ "yes" if simple.number == 0 else "no" }}
+{{ "a" if false else "b" if true else "c" }}
+{{ "a" if false }}
+{{ 1 + 1 if true else 0 }}
+{{ "x" if false or true else "y" }}
+
+null-coalescing
+{{ simple.nil ?? "Guest" }}
+{{ simple.name ?? "Guest" }}
+{{ simple.missing.name ?? simple.nil ?? "fallback" }}
+{{ (simple.nil ?? "guest")|upper }}
+{{ simple.nil ?? "a" if true else "b" }}
\ No newline at end of file
@@ -0,0 +1,321 @@
+package pongo2
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TestFunction is the type test functions must fulfil.
+// A test is used with the "is" operator, like: {% if count is divisibleby(3) %}
+type TestFunction func(in *Value, param *Value) (bool, *Error)
+
+// TestExists returns true if the given test is already registered
+func (set *TemplateSet) TestExists(name string) bool {
+	_, existing := set.tests[name]
+	return existing
+}
+
+// RegisterTest registers a new test. If there's already a test with the same name, it returns an error.
+func (set *TemplateSet) RegisterTest(name string, fn TestFunction) error {
+	if set.TestExists(name) {
+		return fmt.Errorf("test with name '%s' is already registered", name)
+	}
+	set.tests[name] = fn
+	return nil
+}
+
+// ReplaceTest replaces an already registered test with a new implementation. Use this
+// function with caution since it allows you to change existing test behaviour.
+func (set *TemplateSet) ReplaceTest(name string, fn TestFunction) error {
+	if !set.TestExists(name) {
+		return fmt.Errorf("test with name '%s' does not exist (therefore cannot be overridden)", name)
+	}
+	set.tests[name] = fn
+	return nil
+}
+
+// ApplyTest applies a test to a given value using the given parameter.
+func (set *TemplateSet) ApplyTest(name string, value *Value, param *Value) (bool, *Error) {
+	fn, existing := set.tests[name]
+	if !existing {
+		return false, &Error{
+			Sender:    "applytest",
+			OrigError: fmt.Errorf("test with name '%s' not found", name),
+		}
+	}
+
+	// Make sure param is a *Value
+	if param == nil {
+		param = AsValue(nil)
+	}
+
+	return fn(value, param)
+}
+
+// testExpression is: expr is [not] name[(param)]
+type testExpression struct {
+	expr    IEvaluator
+	name    string
+	negate  bool
+	param   IEvaluator
+	testFn  TestFunction
+	opToken *Token
+}
+
+func (expr *testExpression) FilterApplied(name string) bool {
+	return false
+}
+
+func (expr *testExpression) GetPositionToken() *Token {
+	return expr.expr.GetPositionToken()
+}
+
+func (expr *testExpression) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	value, err := expr.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	writer.WriteString(value.String())
+	return nil
+}
+
+func (expr *testExpression) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
+	var result bool
+	if expr.name == "defined" || expr.name == "undefined" {
+		// "defined" tells a missing variable from a variable set to nil, so it needs the variable itself.
+		defined, err := isDefined(ctx, expr.expr)
+		if err != nil {
+			return nil, err
+		}
+		result = defined == (expr.name == "defined")
+	} else {
+		v, err := expr.expr.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		param := AsValue(nil)
+		if expr.param != nil {
+			param, err = expr.param.Evaluate(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result, err = expr.testFn(v, param)
+		if err != nil {
+			return nil, err.updateFromTokenIfNeeded(ctx.template, expr.opToken)
+		}
+	}
+
+	if expr.negate {
+		result = !result
+	}
+	return AsValue(result), nil
+}
+
+// parseTest parses a factor followed by an optional test: IDENT "is" ["not"] IDENT ["(" expr ")"]
+func (p *Parser) parseTest() (IEvaluator, *Error) {
+	expr1, err := p.parsePower()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Peek(TokenIdentifier, "is") == nil {
+		return expr1, nil
+	}
+
+	expr := &testExpression{
+		expr:    expr1,
+		opToken: p.Current(),
+	}
+	p.Consume() // consume 'is'
+
+	if p.Match(TokenKeyword, "not") != nil {
+		expr.negate = true
+	}
+
+	nameToken := p.MatchType(TokenIdentifier)
+	if nameToken == nil {
+		return nil, p.Error("Test name must be an identifier.", nil)
+	}
+	testFn, exists := p.template.set.tests[nameToken.Val]
+	if !exists {
+		return nil, p.Error(fmt.Sprintf("Test '%s' does not exist.", nameToken.Val), nameToken)
+	}
+	expr.name = nameToken.Val
+	expr.testFn = testFn
+
+	if p.Match(TokenSymbol, "(") != nil {
+		param, err := p.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if p.Match(TokenSymbol, ")") == nil {
+			return nil, p.Error("Closing bracket expected after test argument", nil)
+		}
+		expr.param = param
+	}
+
+	return expr, nil
+}
+
+// isDefined reports whether the variable exists in the context, even if its value is nil.
+// An expression other than a variable is defined if it evaluates to a non-nil value.
+func isDefined(ctx *ExecutionContext, expr IEvaluator) (bool, *Error) {
+	if fv, ok := expr.(*nodeFilteredVariable); ok && len(fv.filterChain) == 0 {
+		expr = fv.resolver
+	}
+	vr, ok := expr.(*variableResolver)
+	if !ok || len(vr.parts) == 0 || vr.parts[0].typ != varTypeIdent {
+		v, err := ctx.evaluateAllowUndefined(expr)
+		if err != nil {
+			return false, err
+		}
+		return !v.IsNil(), nil
+	}
+
+	if len(vr.parts) == 1 {
+		name := vr.parts[0].s
+		if _, ok := ctx.Private[name]; ok {
+			return true, nil
+		}
+		_, ok := ctx.Public[name]
+		return ok, nil
+	}
+
+	// resolve the parent, and look up the last part in it
+	parent := &variableResolver{locationToken: vr.locationToken, parts: vr.parts[:len(vr.parts)-1]}
+	pv, err := ctx.evaluateAllowUndefined(parent)
+	if err != nil {
+		return false, err
+	}
+	current := pv.val
+	if current.IsValid() && current.Kind() == reflect.Interface {
+		current = current.Elem()
+	}
+	if !current.IsValid() {
+		return false, nil
+	}
+
+	last := vr.parts[len(vr.parts)-1]
+	switch last.typ {
+	case varTypeIdent:
+		if current.MethodByName(last.s).IsValid() {
+			return true, nil
+		}
+		if current.Kind() == reflect.Ptr {
+			if current.IsNil() {
+				return false, nil
+			}
+			current = current.Elem()
+		}
+		switch current.Kind() {
+		case reflect.Struct:
+			return current.FieldByName(last.s).IsValid(), nil
+		case reflect.Map:
+			return current.MapIndex(reflect.ValueOf(last.s)).IsValid(), nil
+		}
+		return false, nil
+	case varTypeInt:
+		switch current.Kind() {
+		case reflect.String, reflect.Array, reflect.Slice:
+			return last.i >= 0 && last.i < current.Len(), nil
+		}
+		return false, nil
+	}
+
+	// a subscript is defined if it evaluates to a non-nil value
+	v, err2 := ctx.evaluateAllowUndefined(vr)
+	if err2 != nil {
+		return false, err2
+	}
+	return !v.IsNil(), nil
+}
+
+func testDefined(in *Value, param *Value) (bool, *Error) {
+	// Used only by ApplyTest. In templates, the variable itself is checked.
+	return !in.IsNil(), nil
+}
+
+func testUndefined(in *Value, param *Value) (bool, *Error) {
+	return in.IsNil(), nil
+}
+
+func testNone(in *Value, param *Value) (bool, *Error) {
+	return in.IsNil(), nil
+}
+
+func testEven(in *Value, param *Value) (bool, *Error) {
+	if !in.IsNumber() {
+		return false, nil
+	}
+	return in.Integer()%2 == 0, nil
+}
+
+func testOdd(in *Value, param *Value) (bool, *Error) {
+	if !in.IsNumber() {
+		return false, nil
+	}
+	return in.Integer()%2 != 0, nil
+}
+
+func testDivisibleBy(in *Value, param *Value) (bool, *Error) {
+	if param.Integer() == 0 {
+		return false, &Error{
+			Sender:    "test:divisibleby",
+			OrigError: fmt.Errorf("divisibleby needs a non-zero number as argument"),
+		}
+	}
+	return in.Integer()%param.Integer() == 0, nil
+}
+
+func testIterable(in *Value, param *Value) (bool, *Error) {
+	switch in.getResolvedValue().Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.String:
+		return true, nil
+	}
+	return false, nil
+}
+
+func testMapping(in *Value, param *Value) (bool, *Error) {
+	return in.getResolvedValue().Kind() == reflect.Map, nil
+}
+
+func testString(in *Value, param *Value) (bool, *Error) {
+	return in.IsString(), nil
+}
+
+func testNumber(in *Value, param *Value) (bool, *Error) {
+	return in.IsNumber(), nil
+}
+
+func testSameAs(in *Value, param *Value) (bool, *Error) {
+	if in.IsNil() || param.IsNil() {
+		return in.IsNil() && param.IsNil(), nil
+	}
+	v1, v2 := in.getResolvedValue(), param.getResolvedValue()
+	switch in.val.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if in.val.Kind() != param.val.Kind() {
+			return false, nil
+		}
+		return in.val.Pointer() == param.val.Pointer(), nil
+	}
+	if v1.Type() != v2.Type() || !v1.Type().Comparable() {
+		return false, nil
+	}
+	return v1.Interface() == v2.Interface(), nil
+}
+
+func init() {
+	RegisterTest("defined", testDefined)
+	RegisterTest("undefined", testUndefined)
+	RegisterTest("none", testNone)
+	RegisterTest("even", testEven)
+	RegisterTest("odd", testOdd)
+	RegisterTest("divisibleby", testDivisibleBy)
+	RegisterTest("iterable", testIterable)
+	RegisterTest("mapping", testMapping)
+	RegisterTest("string", testString)
+	RegisterTest("number", testNumber)
+	RegisterTest("sameas", testSameAs)
+}
@@ -0,0 +1,78 @@
+package pongo2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testsUser struct {
+	Name  string
+	Email *string
+}
+
+func (u *testsUser) DisplayName() string {
+	return u.Name
+}
+
+func TestTests(t *testing.T) {
+	set := NewSet("test", &DummyLoader{})
+	user := &testsUser{Name: "john"}
+	ctx := Context{
+		"nil_value": nil,
+		"number":    9,
+		"text":      "abc",
+		"list":      []int{1, 2},
+		"dict":      map[string]any{"a": nil},
+		"user":      user,
+		"same_user": user,
+		"other":     &testsUser{Name: "john"},
+	}
+
+	tests := []struct {
+		template string
+		output   string
+	}{
+		{`{{ nil_value is defined }} {{ missing is defined }} {{ missing is undefined }} {{ missing is not defined }}`, "True False True True"},
+		{`{{ dict.a is defined }} {{ dict.b is defined }} {{ missing.a is defined }}`, "True False False"},
+		{`{{ user.Name is defined }} {{ user.Email is defined }} {{ user.Age is defined }} {{ user.DisplayName is defined }}`, "True True False True"},
+		{`{{ list.1 is defined }} {{ list.2 is defined }}`, "True False"},
+		{`{{ nil_value is none }} {{ missing is none }} {{ number is none }} {{ user.Email is none }}`, "True True False True"},
+		{`{{ number is odd }} {{ number is even }} {{ number is divisibleby(3) }} {{ number is not divisibleby(2) }}`, "True False True True"},
+		{`{{ list is iterable }} {{ text is iterable }} {{ number is iterable }} {{ dict is mapping }}`, "True True False True"},
+		{`{{ text is string }} {{ number is string }} {{ number is number }} {{ text is number }}`, "True False True False"},
+		{`{{ user is sameas(same_user) }} {{ user is sameas(other) }} {{ nil_value is sameas(missing) }}`, "True False True"},
+		{`{% if number is odd and text is string %}yes{% endif %}`, "yes"},
+		{`{% if not missing is defined %}yes{% endif %}`, "yes"},
+		{`{{ (number + 1) is even }}`, "True"},
+	}
+	for _, tt := range tests {
+		out, err := set.RenderTemplateString(tt.template, ctx)
+		assert.NoError(t, err, tt.template)
+		assert.Equal(t, tt.output, out, tt.template)
+	}
+
+	t.Run("custom tests", func(t *testing.T) {
+		set := NewSet("test", &DummyLoader{})
+		assert.NoError(t, set.RegisterTest("admin", func(in *Value, param *Value) (bool, *Error) {
+			return in.String() == "admin", nil
+		}))
+		assert.Error(t, set.RegisterTest("admin", nil))
+		assert.Error(t, set.ReplaceTest("nonexistent", nil))
+
+		out, err := set.RenderTemplateString(`{{ role is admin }}`, Context{"role": "admin"})
+		assert.NoError(t, err)
+		assert.Equal(t, "True", out)
+
+		ok, err2 := set.ApplyTest("divisibleby", AsValue(10), AsValue(5))
+		assert.Nil(t, err2)
+		assert.True(t, ok)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := set.FromString(`{{ number is unknown }}`)
+		assert.ErrorContains(t, err, "Test 'unknown' does not exist.")
+
+		_, err = set.RenderTemplateString(`{{ number is divisibleby(0) }}`, ctx)
+		assert.ErrorContains(t, err, "divisibleby needs a non-zero number as argument")
+	})
+}
@@ -19,6 +19,7 @@ const (
 var (
 	typeOfValuePtr   = reflect.TypeOf(new(Value))
 	typeOfExecCtxPtr = reflect.TypeOf(new(ExecutionContext))
+	typeOfSlotPtr    = reflect.TypeOf(new(Slot))
 )
 
 type variablePart struct {
@@ -30,6 +31,12 @@ type variablePart struct {
 
 	isFunctionCall bool
 	callingArgs    []functionCallArgument // needed for a function call, represents all argument nodes (INode supports nested function calls)
+	callingKwargs  []*keywordArgument     // keyword arguments of a function call, like: route("users.show", id=user.ID)
+}
+
+type keywordArgument struct {
+	name string
+	expr IEvaluator
 }
 
 func (p *variablePart) String() string {
@@ -47,6 +54,13 @@ func (p *variablePart) String() string {
 	panic("unimplemented")
 }
 
+// mapResolver evaluates a map literal like {"size": "sm", "count": n}
+type mapResolver struct {
+	locationToken *Token
+	keys          []string
+	values        []IEvaluator
+}
+
 type functionCallArgument interface {
 	Evaluate(*ExecutionContext) (*Value, *Error)
 }
@@ -170,6 +184,35 @@ func (b *boolResolver) GetPositionToken() *Token {
 	return b.locationToken
 }
 
+func (m *mapResolver) Execute(ctx *ExecutionContext, writer TemplateWriter) *Error {
+	value, err := m.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	writer.WriteString(value.String())
+	return nil
+}
+
+func (m *mapResolver) GetPositionToken() *Token {
+	return m.locationToken
+}
+
+func (m *mapResolver) FilterApplied(name string) bool {
+	return false
+}
+
+func (m *mapResolver) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
+	result := make(map[string]any, len(m.keys))
+	for i, key := range m.keys {
+		val, err := m.values[i].Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = val.Interface()
+	}
+	return AsValue(result), nil
+}
+
 func (s *stringResolver) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 	return AsValue(s.val), nil
 }
@@ -220,7 +263,11 @@ func (nv *nodeVariable) Execute(ctx *ExecutionContext, writer TemplateWriter) *E
 		}
 	}
 
-	writer.WriteString(value.String())
+	out := value.String()
+	if err := ctx.countOutput(len(out), nv.locationToken); err != nil {
+		return err
+	}
+	writer.WriteString(out)
 	return nil
 }
 
@@ -276,7 +323,11 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 			val, inPrivate := ctx.Private[vr.parts[0].s]
 			if !inPrivate {
 				// Nothing found? Then have a final lookup in the public context
-				val = ctx.Public[vr.parts[0].s]
+				var inPublic bool
+				val, inPublic = ctx.Public[vr.parts[0].s]
+				if !inPublic && ctx.strictUndefined() {
+					return nil, vr.undefinedError(idx)
+				}
 			}
 			current = reflect.ValueOf(val) // Get the initial value
 		} else {
@@ -288,6 +339,9 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 			if part.typ == varTypeIdent {
 				funcValue := current.MethodByName(part.s)
 				if funcValue.IsValid() {
+					if sandbox := ctx.sandbox(); sandbox != nil && !sandbox.allowsMethod(current.Type(), part.s) {
+						return nil, vr.sandboxMethodError(idx, current.Type())
+					}
 					current = funcValue
 					isFunc = true
 				}
@@ -299,6 +353,9 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 					current = current.Elem()
 					if !current.IsValid() {
 						// Value is not valid (anymore)
+						if ctx.strictUndefined() {
+							return nil, vr.nilError(idx)
+						}
 						return AsValue(nil), nil
 					}
 				}
@@ -313,6 +370,9 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 						if part.i >= 0 && current.Len() > part.i {
 							current = current.Index(part.i)
 						} else {
+							if ctx.strictUndefined() {
+								return nil, vr.undefinedError(idx)
+							}
 							// In Django, exceeding the length of a list is just empty.
 							return AsValue(nil), nil
 						}
@@ -324,6 +384,9 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 					// Calling a field or key
 					switch current.Kind() {
 					case reflect.Struct:
+						if err := vr.checkField(ctx, idx, current.Type(), part.s); err != nil {
+							return nil, err
+						}
 						current = current.FieldByName(part.s)
 					case reflect.Map:
 						current = current.MapIndex(reflect.ValueOf(part.s))
@@ -344,6 +407,9 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 						if si >= 0 && current.Len() > si {
 							current = current.Index(si)
 						} else {
+							if ctx.strictUndefined() {
+								return nil, vr.undefinedError(idx)
+							}
 							// In Django, exceeding the length of a list is just empty.
 							return AsValue(nil), nil
 						}
@@ -353,6 +419,9 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 						if err != nil {
 							return nil, err
 						}
+						if err := vr.checkField(ctx, idx, current.Type(), sv.String()); err != nil {
+							return nil, err
+						}
 						current = current.FieldByName(sv.String())
 					case reflect.Map:
 						sv, err := part.subscript.Evaluate(ctx)
@@ -360,11 +429,17 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 							return nil, err
 						}
 						if sv.IsNil() {
+							if ctx.strictUndefined() {
+								return nil, vr.undefinedError(idx)
+							}
 							return AsValue(nil), nil
 						}
 						if sv.val.Type().AssignableTo(current.Type().Key()) {
 							current = current.MapIndex(sv.val)
 						} else {
+							if ctx.strictUndefined() {
+								return nil, vr.undefinedError(idx)
+							}
 							return AsValue(nil), nil
 						}
 					default:
@@ -378,6 +453,15 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 		}
 
 		if !current.IsValid() {
+			if ctx.strictUndefined() {
+				if idx > 0 && (part.typ == varTypeIdent || part.typ == varTypeSubscript) {
+					// the field or the key is missing
+					return nil, vr.undefinedError(idx)
+				}
+				if idx < len(vr.parts)-1 {
+					return nil, vr.nilError(idx + 1)
+				}
+			}
 			// Value is not valid (anymore)
 			return AsValue(nil), nil
 		}
@@ -395,6 +479,9 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 		if current.Kind() == reflect.Interface {
 			current = reflect.ValueOf(current.Interface())
 		}
+		if !current.IsValid() && idx < len(vr.parts)-1 && ctx.strictUndefined() {
+			return nil, vr.nilError(idx + 1)
+		}
 
 		// Check if the part is a function call
 		if part.isFunctionCall || current.Kind() == reflect.Func {
@@ -413,11 +500,21 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 				currArgs = append([]functionCallArgument{executionCtxEval{}}, currArgs...)
 			}
 
+			// The keyword arguments are passed by the parameter of the type Kwargs or an options struct
+			kwargsIdx := kwargsParamIndex(t, len(currArgs), len(part.callingKwargs) > 0)
+			if len(part.callingKwargs) > 0 && kwargsIdx < 0 {
+				return nil, fmt.Errorf("'%s' does not accept keyword arguments", vr.String())
+			}
+			numIn := t.NumIn()
+			if kwargsIdx >= 0 {
+				numIn--
+			}
+
 			// Input arguments
-			if len(currArgs) != t.NumIn() && !(len(currArgs) >= t.NumIn()-1 && t.IsVariadic()) {
+			if len(currArgs) != numIn && !(len(currArgs) >= numIn-1 && t.IsVariadic()) {
 				return nil,
 					fmt.Errorf("function input argument count (%d) of '%s' must be equal to the calling argument count (%d)",
-						t.NumIn(), vr.String(), len(currArgs))
+						numIn, vr.String(), len(currArgs))
 			}
 
 			// Output arguments
@@ -438,14 +535,20 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 					return nil, err
 				}
 
+				// skip the parameter of the keyword arguments
+				paramIdx := idx
+				if kwargsIdx >= 0 && idx >= kwargsIdx {
+					paramIdx++
+				}
+
 				if isVariadic {
-					if idx >= t.NumIn()-1 {
+					if paramIdx >= t.NumIn()-1 {
 						fnArg = t.In(numArgs - 1).Elem()
 					} else {
-						fnArg = t.In(idx)
+						fnArg = t.In(paramIdx)
 					}
 				} else {
-					fnArg = t.In(idx)
+					fnArg = t.In(paramIdx)
 				}
 
 				if fnArg != typeOfValuePtr {
@@ -475,6 +578,23 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 				}
 			}
 
+			// Evaluate the keyword arguments
+			if kwargsIdx >= 0 {
+				kwargs := make(Kwargs, len(part.callingKwargs))
+				for _, kwarg := range part.callingKwargs {
+					pv, err := kwarg.expr.Evaluate(ctx)
+					if err != nil {
+						return nil, err
+					}
+					kwargs[kwarg.name] = pv
+				}
+				kv, err := kwargsParam(t.In(kwargsIdx), kwargs)
+				if err != nil {
+					return nil, fmt.Errorf("keyword arguments of '%s': %w", vr.String(), err)
+				}
+				parameters = append(parameters[:kwargsIdx], append([]reflect.Value{kv}, parameters[kwargsIdx:]...)...)
+			}
+
 			// Check if any of the values are invalid
 			for _, p := range parameters {
 				if p.Kind() == reflect.Invalid {
@@ -511,15 +631,82 @@ func (vr *variableResolver) resolve(ctx *ExecutionContext) (*Value, error) {
 			// Value is not valid (e. g. NIL value)
 			return AsValue(nil), nil
 		}
+
+		// The sandbox policy blocks the access to the dangerous types
+		if sandbox := ctx.sandbox(); sandbox != nil {
+			if blocked := sandbox.blockedType(current.Type()); blocked != nil {
+				return nil, vr.sandboxTypeError(idx, blocked)
+			}
+		}
+	}
+
+	// A slot resolves to its rendered content, so it is output and filtered like a safe string.
+	// Its attributes and methods are still accessible, like footer.Attributes.
+	if current.IsValid() && current.Type() == typeOfSlotPtr {
+		val, err := current.Interface().(*Slot).value()
+		if err != nil {
+			return nil, err
+		}
+		return val, nil
+	}
+
+	// The sandbox policy blocks the dangerous values in the maps and the slices as well, which are output with %v
+	if sandbox := ctx.sandbox(); sandbox != nil {
+		if blocked := sandbox.blockedValue(current); blocked != nil {
+			return nil, vr.sandboxTypeError(len(vr.parts)-1, blocked)
+		}
 	}
 
 	return &Value{val: current, safe: isSafe}, nil
 }
 
+// undefinedError returns the error of the strict undefined mode for the undefined part of the variable.
+func (vr *variableResolver) undefinedError(idx int) error {
+	name := (&variableResolver{parts: vr.parts[:idx+1]}).String()
+	return fmt.Errorf("'%s' is undefined (variable %s)", name, vr.String())
+}
+
+// nilError returns the error of the strict undefined mode for the part of the variable accessed on nil.
+func (vr *variableResolver) nilError(idx int) error {
+	name := (&variableResolver{parts: vr.parts[:idx]}).String()
+	return fmt.Errorf("can't access '%s' on nil '%s' (variable %s)", vr.parts[idx].String(), name, vr.String())
+}
+
+// checkField returns an error if the sandbox policy disallows the access to the field of the struct type.
+func (vr *variableResolver) checkField(ctx *ExecutionContext, idx int, t reflect.Type, name string) error {
+	if ctx.sandbox() == nil {
+		return nil
+	}
+	if field, ok := t.FieldByName(name); ok && !field.IsExported() {
+		return &SandboxError{
+			Violation: SandboxUnexportedField,
+			Message:   fmt.Sprintf("access to the unexported field '%s' of %s is not allowed (variable %s)", name, t.String(), vr.String()),
+		}
+	}
+	return nil
+}
+
+// sandboxMethodError returns the error of the sandbox policy for the method not in the allowlist.
+func (vr *variableResolver) sandboxMethodError(idx int, t reflect.Type) error {
+	return &SandboxError{
+		Violation: SandboxMethodNotAllowed,
+		Message:   fmt.Sprintf("calling the method '%s' of %s is not allowed (variable %s)", vr.parts[idx].s, t.String(), vr.String()),
+	}
+}
+
+// sandboxTypeError returns the error of the sandbox policy for the part of the variable of a blocked type.
+func (vr *variableResolver) sandboxTypeError(idx int, blocked reflect.Type) error {
+	name := (&variableResolver{parts: vr.parts[:idx+1]}).String()
+	return &SandboxError{
+		Violation: SandboxTypeBlocked,
+		Message:   fmt.Sprintf("access to '%s' of type %s is not allowed (variable %s)", name, blocked.String(), vr.String()),
+	}
+}
+
 func (vr *variableResolver) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
 	value, err := vr.resolve(ctx)
 	if err != nil {
-		return AsValue(nil), ctx.Error(err.Error(), vr.locationToken)
+		return AsValue(nil), ctx.OrigError(err, vr.locationToken)
 	}
 	return value, nil
 }
@@ -534,7 +721,14 @@ func (v *nodeFilteredVariable) FilterApplied(name string) bool {
 }
 
 func (v *nodeFilteredVariable) Evaluate(ctx *ExecutionContext) (*Value, *Error) {
-	value, err := v.resolver.Evaluate(ctx)
+	var value *Value
+	var err *Error
+	if v.FilterApplied("default") || v.FilterApplied("default_if_none") {
+		// The default filter gives a value to an undefined variable.
+		value, err = ctx.evaluateAllowUndefined(v.resolver)
+	} else {
+		value, err = v.resolver.Evaluate(ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -592,6 +786,52 @@ func (p *Parser) parseArray() (IEvaluator, *Error) {
 	return resolver, nil
 }
 
+// "{" [STRING ":" expr {, STRING ":" expr}] "}"
+func (p *Parser) parseMap() (IEvaluator, *Error) {
+	m := &mapResolver{
+		locationToken: p.Current(),
+	}
+	p.Consume() // We consume '{'
+
+	// We allow an empty map, so check for a closing brace.
+	if p.Match(TokenSymbol, "}") != nil {
+		return m, nil
+	}
+
+	// parsing a map declaration with at least one key-value pair
+	for {
+		if p.Remaining() == 0 {
+			return nil, p.Error("Unexpected EOF, unclosed map literal.", p.lastToken)
+		}
+
+		keyToken := p.MatchType(TokenString)
+		if keyToken == nil {
+			return nil, p.Error("Expected a string as a map key.", p.Current())
+		}
+		if p.Match(TokenSymbol, ":") == nil {
+			return nil, p.Error("Expected ':' after a map key.", p.Current())
+		}
+		valueExpr, err := p.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		m.keys = append(m.keys, keyToken.Val)
+		m.values = append(m.values, valueExpr)
+
+		if p.Match(TokenSymbol, "}") != nil {
+			// If there's a closing brace after an expression, we will stop parsing the pairs
+			break
+		}
+
+		// If there's NO closing brace, there MUST be an comma
+		if p.Match(TokenSymbol, ",") == nil {
+			return nil, p.Error("Missing comma or closing brace after map value.", p.Current())
+		}
+	}
+
+	return m, nil
+}
+
 // IDENT | IDENT.(IDENT|NUMBER)... | IDENT[expr]... | "[" [ expr {, expr}] "]"
 func (p *Parser) parseVariableOrLiteral() (IEvaluator, *Error) {
 	t := p.Current()
@@ -665,6 +905,10 @@ func (p *Parser) parseVariableOrLiteral() (IEvaluator, *Error) {
 			// Parsing an array literal [expr {, expr}]
 			return p.parseArray()
 		}
+		if t.Val == "{" {
+			// Parsing a map literal {"key": expr {, "key": expr}}
+			return p.parseMap()
+		}
 	}
 
 	resolver := &variableResolver{
@@ -745,36 +989,12 @@ variableLoop:
 			// FunctionName '(' Comma-separated list of expressions ')'
 			part := resolver.parts[len(resolver.parts)-1]
 			part.isFunctionCall = true
-		argumentLoop:
-			for {
-				if p.Remaining() == 0 {
-					return nil, p.Error("Unexpected EOF, expected function call argument list.", p.lastToken)
-				}
-
-				if p.Peek(TokenSymbol, ")") == nil {
-					// No closing bracket, so we're parsing an expression
-					exprArg, err := p.ParseExpression()
-					if err != nil {
-						return nil, err
-					}
-					part.callingArgs = append(part.callingArgs, exprArg)
-
-					if p.Match(TokenSymbol, ")") != nil {
-						// If there's a closing bracket after an expression, we will stop parsing the arguments
-						break argumentLoop
-					} else {
-						// If there's NO closing bracket, there MUST be an comma
-						if p.Match(TokenSymbol, ",") == nil {
-							return nil, p.Error("Missing comma or closing bracket after argument.", nil)
-						}
-					}
-				} else {
-					// We got a closing bracket, so stop parsing arguments
-					p.Consume()
-					break argumentLoop
-				}
-
+			args, kwargs, err := p.parseCallArguments()
+			if err != nil {
+				return nil, err
 			}
+			part.callingArgs = args
+			part.callingKwargs = kwargs
 			// We're done parsing the function call, next variable part
 			continue variableLoop
 		}
@@ -799,22 +1019,8 @@ func (p *Parser) parseVariableOrLiteralWithFilter() (*nodeFilteredVariable, *Err
 	v.resolver = resolver
 
 	// Parse all the filters
-filterLoop:
-	for p.Match(TokenSymbol, "|") != nil {
-		// Parse one single filter
-		filter, err := p.parseFilter()
-		if err != nil {
-			return nil, err
-		}
-
-		// Check sandbox filter restriction
-		if _, isBanned := p.template.set.bannedFilters[filter.name]; isBanned {
-			return nil, p.Error(fmt.Sprintf("Usage of filter '%s' is not allowed (sandbox restriction active).", filter.name), nil)
-		}
-
-		v.filterChain = append(v.filterChain, filter)
-
-		continue filterLoop
+	if err := p.parseFilterChain(v); err != nil {
+		return nil, err
 	}
 
 	return v, nil
@@ -839,3 +1045,78 @@ func (p *Parser) parseVariableElement() (INode, *Error) {
 
 	return node, nil
 }
+
+// parseCallArguments parses the arguments of a call after the opening bracket:
+// [expr {, expr}] {, IDENT "=" expr} ")"
+func (p *Parser) parseCallArguments() ([]functionCallArgument, []*keywordArgument, *Error) {
+	var args []functionCallArgument
+	var kwargs []*keywordArgument
+	for {
+		if p.Remaining() == 0 {
+			return nil, nil, p.Error("Unexpected EOF, expected function call argument list.", p.lastToken)
+		}
+
+		if p.Match(TokenSymbol, ")") != nil {
+			// We got a closing bracket, so stop parsing arguments
+			return args, kwargs, nil
+		}
+
+		if p.PeekType(TokenIdentifier) != nil && p.PeekN(1, TokenSymbol, "=") != nil {
+			// Keyword argument: name=expr
+			nameToken := p.Current()
+			p.ConsumeN(2)
+			exprArg, err := p.ParseExpression()
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, kwarg := range kwargs {
+				if kwarg.name == nameToken.Val {
+					return nil, nil, p.Error(fmt.Sprintf("Keyword argument '%s' repeated.", nameToken.Val), nameToken)
+				}
+			}
+			kwargs = append(kwargs, &keywordArgument{name: nameToken.Val, expr: exprArg})
+		} else {
+			if len(kwargs) > 0 {
+				return nil, nil, p.Error("Positional argument follows keyword argument.", nil)
+			}
+			// No closing bracket, so we're parsing an expression
+			exprArg, err := p.ParseExpression()
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, exprArg)
+		}
+
+		if p.Match(TokenSymbol, ")") != nil {
+			// If there's a closing bracket after an expression, we will stop parsing the arguments
+			return args, kwargs, nil
+		}
+		// If there's NO closing bracket, there MUST be an comma
+		if p.Match(TokenSymbol, ",") == nil {
+			return nil, nil, p.Error("Missing comma or closing bracket after argument.", nil)
+		}
+	}
+}
+
+// parseFilterChain parses the filters applied to the variable: |filter1|filter2:arg
+func (p *Parser) parseFilterChain(v *nodeFilteredVariable) *Error {
+filterLoop:
+	for p.Match(TokenSymbol, "|") != nil {
+		// Parse one single filter
+		filter, err := p.parseFilter()
+		if err != nil {
+			return err
+		}
+
+		// Check sandbox filter restriction
+		if _, isBanned := p.template.set.bannedFilters[filter.name]; isBanned {
+			return p.Error(fmt.Sprintf("Usage of filter '%s' is not allowed (sandbox restriction active).", filter.name), nil)
+		}
+
+		v.filterChain = append(v.filterChain, filter)
+
+		continue filterLoop
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 
 	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
 )
 
 func IsDebugFunctionProvider(debug bool) SharedContextProviderFunc {
@@ -77,3 +78,46 @@ func JsonMarshalFunctionProvider() SharedContextProviderFunc {
 		}, nil
 	}
 }
+
+// AcceptLanguageLocaleProvider returns a provider of the locale of the user by the Accept-Language request header.
+// It returns the best match in the supported locales, or the default locale if none matches.
+func AcceptLanguageLocaleProvider(defaultLocale string, supportedLocales ...string) SharedContextProviderFunc {
+	match := newLocaleMatcher(defaultLocale, supportedLocales)
+	return func(c echo.Context) (any, error) {
+		return match(c.Request().Header.Get("Accept-Language")), nil
+	}
+}
+
+// newLocaleMatcher returns a function that returns the supported locale that matches the Accept-Language header best,
+// or the default locale if none matches. The supported locales can be written like "pt-BR" or "pt_BR".
+// The locales that are not valid BCP 47 language tags are ignored.
+func newLocaleMatcher(defaultLocale string, supportedLocales []string) func(header string) string {
+	var tags []language.Tag
+	var locales []string
+	for _, locale := range supportedLocales {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, tag)
+		locales = append(locales, locale)
+	}
+	if len(tags) == 0 {
+		return func(string) string {
+			return defaultLocale
+		}
+	}
+	matcher := language.NewMatcher(tags)
+
+	return func(header string) string {
+		desired, _, err := language.ParseAcceptLanguage(header)
+		if err != nil || len(desired) == 0 {
+			return defaultLocale
+		}
+		_, index, confidence := matcher.Match(desired...)
+		if confidence == language.No {
+			return defaultLocale
+		}
+		return locales[index]
+	}
+}
@@ -0,0 +1,49 @@
+package viewkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLocaleMatcher(t *testing.T) {
+	match := newLocaleMatcher("en", []string{"en", "ja", "pt_BR", "zh-Hant"})
+
+	tests := []struct {
+		header string
+		locale string
+	}{
+		{"", "en"},
+		{"ja", "ja"},
+		{"ja-JP,ja;q=0.9,en;q=0.8", "ja"},
+		{"fr-FR,fr;q=0.9,ja;q=0.5", "ja"},
+		{"en;q=0.5,ja;q=0.8", "ja"},
+		{"pt-BR", "pt_BR"},
+		{"pt", "pt_BR"},
+		{"zh-TW", "zh-Hant"},
+		{"ja;q=0,en", "en"},
+		{"fr", "en"},
+		{"*", "en"},
+		{"invalid;;q=x", "en"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.locale, match(tt.header), tt.header)
+	}
+
+	// no valid supported locales
+	assert.Equal(t, "en", newLocaleMatcher("en", []string{"not a locale"})("ja"))
+}
+
+func TestAcceptLanguageLocaleProvider(t *testing.T) {
+	provider := AcceptLanguageLocaleProvider("en", "en", "ja")
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "ja-JP,ja;q=0.9")
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+
+	locale, err := provider(c)
+	assert.NoError(t, err)
+	assert.Equal(t, "ja", locale)
+}
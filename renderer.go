@@ -33,27 +33,54 @@ func (r *Renderer) Render(w io.Writer, name string, data any, c echo.Context) er
 	if err != nil {
 		return err
 	}
-	pongo2Context, err := pongo2.MarshalContext(data)
+	pongo2Context, err := r.newContext(data, c)
 	if err != nil {
 		return err
 	}
 
+	if fragmentName != "" {
+		err = t.ExecuteFragmentWriterWithEchoContext(pongo2Context, fragmentName, w, c)
+	} else {
+		err = t.ExecuteWriterWithEchoContext(pongo2Context, w, c)
+	}
+	return unwrapRenderError(err)
+}
+
+// unwrapRenderError returns the HTTP error or the redirect error returned by a component,
+// so that the error handler of Echo can handle it.
+func unwrapRenderError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he
+	}
+	var re *pongo2.RedirectError
+	if errors.As(err, &re) {
+		return re
+	}
+	return err
+}
+
+// newContext creates a template context from the data and the values of the shared context providers.
+func (r *Renderer) newContext(data any, c echo.Context) (pongo2.Context, error) {
+	pongo2Context, err := pongo2.MarshalContext(data)
+	if err != nil {
+		return nil, err
+	}
+
 	for k, provider := range r.providers {
 		v, err := provider(c)
 		if err != nil {
 			if errors.Is(err, ErrSkipAssignment) {
 				continue
 			}
-			return err
+			return nil, err
 		}
 		pongo2Context[k] = v
 	}
-
-	if fragmentName != "" {
-		return t.ExecuteFragmentWriterWithEchoContext(pongo2Context, fragmentName, w, c)
-	} else {
-		return t.ExecuteWriterWithEchoContext(pongo2Context, w, c)
-	}
+	return pongo2Context, nil
 }
 
 // SharedContextProviderFunc is a function that provides shared context data
@@ -64,6 +91,10 @@ type SharedContextProviderFunc func(c echo.Context) (any, error)
 func Render(renderer echo.Renderer, c echo.Context, code int, name string, data any) (err error) {
 	buf := new(bytes.Buffer)
 	if err = renderer.Render(buf, name, data, c); err != nil {
+		var re *pongo2.RedirectError
+		if errors.As(err, &re) {
+			return c.Redirect(re.Code, re.URL)
+		}
 		return
 	}
 	return c.HTMLBlob(code, buf.Bytes())
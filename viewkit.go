@@ -8,11 +8,20 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type ViewKit struct {
 	// Debug enables debug mode.
 	Debug bool
+	// StrictUndefined makes referencing an undefined variable, a missing field or map key an error
+	// instead of rendering it as empty. It is useful to enable it with Debug and in tests.
+	StrictUndefined bool
+	// Sandbox restricts what the templates can do, for the templates written by untrusted users.
+	Sandbox *pongo2.SandboxPolicy
+	// RenderTimeout is the maximum duration of rendering a template. If it is zero, there is no timeout.
+	// Rendering is also aborted when the request is canceled, like when the client disconnects.
+	RenderTimeout time.Duration
 
 	// Templates
 
@@ -33,6 +42,8 @@ type ViewKit struct {
 	PreProcessors []pongo2.PreProcessor
 	// Filters is a map of filters to be registered.
 	Filters map[string]pongo2.FilterFunction
+	// FiltersWithArgs is a map of filters that accept multiple positional and keyword arguments to be registered.
+	FiltersWithArgs map[string]pongo2.FilterFunctionWithArgs
 	// Tags is a map of tags to be registered.
 	Tags map[string]pongo2.TagParser
 	// Component config
@@ -55,6 +66,29 @@ type ViewKit struct {
 	InlineComponents []*pongo2.InlineComponent
 	// Components is a list of components.
 	Components []*pongo2.Component
+	// ClassComponents is a list of components defined by Go types.
+	ClassComponents []*pongo2.ClassComponentDefinition
+	// ComponentLibraries is a list of component libraries.
+	// The components of a library are used with its namespace, like “<x-ui::button>”.
+	ComponentLibraries []*pongo2.ComponentLibrary
+	// ComponentLibraryOverrideDir is a directory to override the templates of the component libraries.
+	// A template “ui::components/button.html” is overridden by “vendor/ui/components/button.html” in the application templates.
+	// The default value is “vendor”.
+	ComponentLibraryOverrideDir string
+	// Cache is a store for the output of the cached components and the cache tag.
+	// If it is nil, an in-memory LRU cache is used.
+	Cache pongo2.Cache
+	// TimezoneKey is the key of the shared context that has the time zone of the user, like "timezone".
+	// The date filters format times in this time zone.
+	TimezoneKey string
+	// Location is the default time zone of the date filters.
+	// If it is nil, times are formatted in their own location.
+	Location *time.Location
+	// Catalog is the store of the translated messages used by the trans and blocktrans tags and the _ function.
+	Catalog pongo2.Catalog
+	// LocaleKey is the key of the shared context that has the locale of the user, like "locale".
+	// The messages are translated into this locale, and the number filters format numbers in it.
+	LocaleKey string
 	// Shared context
 
 	// SharedContextProviders is a map of shared context providers.
@@ -102,16 +136,21 @@ type ViewKit struct {
 func New() *ViewKit {
 	return &ViewKit{
 		Debug:                                 false,
+		StrictUndefined:                       false,
 		FS:                                    nil,
 		FSBaseDir:                             "",
 		BaseDir:                               "",
 		DefaultTemplateFileExtension:          ".html",
 		PreProcessors:                         []pongo2.PreProcessor{},
 		Filters:                               map[string]pongo2.FilterFunction{},
+		FiltersWithArgs:                       map[string]pongo2.FilterFunctionWithArgs{},
 		Tags:                                  map[string]pongo2.TagParser{},
 		DisableComponentHTMLTag:               false,
 		ComponentHTMLTagPrefix:                "x-",
 		Components:                            []*pongo2.Component{},
+		ClassComponents:                       []*pongo2.ClassComponentDefinition{},
+		ComponentLibraries:                    []*pongo2.ComponentLibrary{},
+		ComponentLibraryOverrideDir:           "vendor",
 		AnonymousComponentsDirectories:        []*pongo2.AnonymousComponentsDirectory{},
 		InlineComponents:                      []*pongo2.InlineComponent{},
 		HeadlessComponents:                    []*pongo2.HeadlessComponent{},
@@ -165,6 +204,17 @@ func (v *ViewKit) Renderer() (*Renderer, error) {
 		return nil, fmt.Errorf("FS or BaseDir is required")
 	}
 
+	if len(v.ComponentLibraries) > 0 {
+		// use namespace loader to load the templates of the component libraries.
+		nsLoader := pongo2.NewNamespaceLoader(loader, v.ComponentLibraryOverrideDir)
+		for _, lib := range v.ComponentLibraries {
+			if lib.FS != nil {
+				nsLoader.AddNamespace(lib.Namespace, lib.FS)
+			}
+		}
+		loader = nsLoader
+	}
+
 	if v.DefaultTemplateFileExtension != "" {
 		// use omit extension loader to load templates without the default file extension.
 		loader = pongo2.NewOmitExtensionLoader(loader, v.DefaultTemplateFileExtension)
@@ -185,6 +235,16 @@ func (v *ViewKit) Renderer() (*Renderer, error) {
 	// template set
 	ts := pongo2.NewSet("renderer", loader)
 	ts.Debug = v.Debug
+	ts.StrictUndefined = v.StrictUndefined
+	ts.Sandbox = v.Sandbox
+	ts.RenderTimeout = v.RenderTimeout
+	ts.TimezoneKey = v.TimezoneKey
+	ts.Location = v.Location
+	ts.Catalog = v.Catalog
+	ts.LocaleKey = v.LocaleKey
+	if v.Cache != nil {
+		ts.Cache = v.Cache
+	}
 
 	// configuration for components
 	ts.ComponentSet.TemplateSetFS = templateSetFS
@@ -196,6 +256,11 @@ func (v *ViewKit) Renderer() (*Renderer, error) {
 			return nil, fmt.Errorf("failed to register filter %s: %w", name, err)
 		}
 	}
+	for name, filter := range v.FiltersWithArgs {
+		if err := ts.RegisterFilterWithArgs(name, filter); err != nil {
+			return nil, fmt.Errorf("failed to register filter %s: %w", name, err)
+		}
+	}
 
 	// register tags
 	for name, tag := range v.Tags {
@@ -211,16 +276,34 @@ func (v *ViewKit) Renderer() (*Renderer, error) {
 		}
 	}
 	for _, comp := range v.AnonymousComponents {
-		ts.ComponentSet.RegisterAnonymousComponent(comp)
+		if err := ts.ComponentSet.RegisterAnonymousComponent(comp); err != nil {
+			return nil, err
+		}
 	}
 	for _, comp := range v.HeadlessComponents {
-		ts.ComponentSet.RegisterHeadlessComponent(comp)
+		if err := ts.ComponentSet.RegisterHeadlessComponent(comp); err != nil {
+			return nil, err
+		}
 	}
 	for _, comp := range v.InlineComponents {
-		ts.ComponentSet.RegisterInlineComponent(comp)
+		if err := ts.ComponentSet.RegisterInlineComponent(comp); err != nil {
+			return nil, err
+		}
 	}
 	for _, comp := range v.Components {
-		ts.ComponentSet.RegisterComponent(comp)
+		if err := ts.ComponentSet.RegisterComponent(comp); err != nil {
+			return nil, err
+		}
+	}
+	for _, comp := range v.ClassComponents {
+		if err := ts.ComponentSet.RegisterClassComponent(comp); err != nil {
+			return nil, err
+		}
+	}
+	for _, lib := range v.ComponentLibraries {
+		if err := ts.ComponentSet.RegisterComponentLibrary(lib); err != nil {
+			return nil, err
+		}
 	}
 
 	// Shared context configuration